@@ -17,6 +17,7 @@ import (
 	"github.com/beckn-one/beckn-onix/core/module/handler"
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 )
 
 // Config struct holds all configurations.
@@ -26,6 +27,8 @@ type Config struct {
 	PluginManager *plugin.ManagerConfig `yaml:"pluginManager"`
 	Modules       []module.Config       `yaml:"modules"`
 	HTTP          httpConfig            `yaml:"http"`
+	Reload        reloadConfig          `yaml:"reload"`
+	Tracing       tracing.Config        `yaml:"tracing"`
 }
 
 type httpConfig struct {
@@ -91,14 +94,15 @@ func validateConfig(cfg *Config) error {
 	return nil
 }
 
-// newServer creates and initializes the HTTP server.
-func newServer(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, error) {
+// newServer creates and initializes the HTTP server. The returned closer releases
+// the resources of the module handlers.
+func newServer(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, func(), error) {
 	mux := http.NewServeMux()
-	err := module.Register(ctx, cfg.Modules, mux, mgr)
+	closer, err := module.Register(ctx, cfg.Modules, mux, mgr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to register modules: %w", err)
+		return nil, nil, fmt.Errorf("failed to register modules: %w", err)
 	}
-	return mux, nil
+	return mux, closer, nil
 }
 
 var newManagerFunc = plugin.NewManager
@@ -116,22 +120,33 @@ func run(ctx context.Context, configPath string) error {
 	if err := log.InitLogger(cfg.Log); err != nil {
 		return fmt.Errorf("failed to initialize logger: %w", err)
 	}
-
-	// Initialize plugin manager.
-	log.Infof(ctx, "Initializing plugin manager")
-	mgr, closer, err := newManagerFunc(ctx, cfg.PluginManager)
+	tracingCfg := cfg.Tracing
+	if tracingCfg.ServiceName == "" {
+		tracingCfg.ServiceName = cfg.AppName
+	}
+	shutdownTracing, err := tracing.Init(ctx, tracingCfg)
 	if err != nil {
-		return fmt.Errorf("failed to create plugin manager: %w", err)
+		return fmt.Errorf("failed to initialize tracing: %w", err)
 	}
-	closers = append(closers, closer)
-	log.Debug(ctx, "Plugin manager loaded.")
 
-	// Initialize HTTP server.
-	log.Infof(ctx, "Initializing HTTP server")
-	srv, err := newServerFunc(ctx, mgr, cfg)
+	// Initialize plugin manager and module handlers.
+	gen, err := newGeneration(ctx, cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize server: %w", err)
+		return err
 	}
+	srv := &reloadableHandler{}
+	srv.swap(gen)
+	// Modules are closed before tracing is shut down, so that their spans are exported.
+	closers = append(closers, srv.close, shutdownTracing)
+
+	// Reload modules when the config file changes or on SIGHUP.
+	hup, stopHup := notifyReload()
+	defer stopHup()
+	go watchConfig(ctx, configPath, cfg.Reload.WatchInterval, hup, func() {
+		if err := srv.reload(ctx, configPath, cfg); err != nil {
+			log.Errorf(ctx, err, "Configuration reload rejected, keeping current modules")
+		}
+	})
 
 	// Configure HTTP server.
 	httpServer := &http.Server{
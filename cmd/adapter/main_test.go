@@ -63,6 +63,11 @@ func (m *MockPluginManager) Cache(ctx context.Context, cfg *plugin.Config) (defi
 	return nil, nil
 }
 
+// Registry returns a mock implementation of the RegistryLookup interface.
+func (m *MockPluginManager) Registry(ctx context.Context, cfg *plugin.Config) (definition.RegistryLookup, error) {
+	return nil, nil
+}
+
 // KeyManager returns a mock implementation of the KeyManager interface.
 func (m *MockPluginManager) KeyManager(ctx context.Context, cache definition.Cache, rLookup definition.RegistryLookup, cfg *plugin.Config) (definition.KeyManager, error) {
 	return nil, nil
@@ -114,8 +119,8 @@ func TestRunSuccess(t *testing.T) {
 	defer func() { newManagerFunc = originalNewManager }()
 
 	originalNewServer := newServerFunc
-	newServerFunc = func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, error) {
-		return http.NewServeMux(), nil
+	newServerFunc = func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, func(), error) {
+		return http.NewServeMux(), nil, nil
 	}
 	defer func() { newServerFunc = originalNewServer }()
 
@@ -131,7 +136,7 @@ func TestRunFailure(t *testing.T) {
 		configData  string
 		mockMgr     func() (*MockPluginManager, func(), error)
 		mockLogger  func(cfg *Config) error
-		mockServer  func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, error)
+		mockServer  func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, func(), error)
 		expectedErr string
 	}{
 		{
@@ -143,8 +148,8 @@ func TestRunFailure(t *testing.T) {
 			mockLogger: func(cfg *Config) error {
 				return nil
 			},
-			mockServer: func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, error) {
-				return nil, errors.New("failed to start server")
+			mockServer: func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, func(), error) {
+				return nil, nil, errors.New("failed to start server")
 			},
 			expectedErr: "failed to initialize config: invalid config: missing app name",
 		},
@@ -172,7 +177,7 @@ func TestRunFailure(t *testing.T) {
 			defer func() { newManagerFunc = originalNewManager }()
 
 			originalNewServer := newServerFunc
-			newServerFunc = func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, error) {
+			newServerFunc = func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, func(), error) {
 				return tt.mockServer(ctx, mgr, cfg)
 			}
 			defer func() { newServerFunc = originalNewServer }()
@@ -303,7 +308,7 @@ func TestNewServerSuccess(t *testing.T) {
 				},
 			}
 
-			handler, err := newServer(context.Background(), mockMgr, cfg)
+			handler, _, err := newServer(context.Background(), mockMgr, cfg)
 
 			if err != nil {
 				t.Errorf("Expected no error, but got: %v", err)
@@ -348,7 +353,7 @@ func TestNewServerFailure(t *testing.T) {
 				},
 			}
 
-			handler, err := newServer(context.Background(), mockMgr, cfg)
+			handler, _, err := newServer(context.Background(), mockMgr, cfg)
 
 			if err == nil {
 				t.Errorf("Expected an error, but got nil")
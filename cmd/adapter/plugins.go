@@ -0,0 +1,25 @@
+//go:build staticplugins
+
+package main
+
+// Importing the provider packages links the bundled plugins into the adapter binary,
+// so the plugin manager resolves them without loading .so files. Build with:
+//
+//	CGO_ENABLED=0 go build -tags staticplugins ./cmd/adapter
+import (
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/cache/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/decrypter/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/dediregistry/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/encrypter/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/keymanager/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/publisher/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/ratelimiter/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/registry/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/reqpreprocessor/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/router/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/schemav2validator/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/schemavalidator/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signer/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signvalidator/provider"
+	_ "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/simplekeymanager/provider"
+)
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+)
+
+// reloadConfig holds the settings for reloading the configuration at runtime.
+type reloadConfig struct {
+	// WatchInterval is how often the config file is checked for changes.
+	// A zero value disables watching; a SIGHUP always triggers a reload.
+	WatchInterval time.Duration `yaml:"watchInterval"`
+}
+
+// generation is one set of module handlers built from a single configuration,
+// together with the closer that releases the plugins it uses.
+type generation struct {
+	handler http.Handler
+	closer  func()
+
+	mu      sync.RWMutex // mu is held for reading by in-flight requests.
+	retired bool
+}
+
+// serve handles the request unless the generation has already been retired.
+func (g *generation) serve(w http.ResponseWriter, r *http.Request) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.retired {
+		return false
+	}
+	g.handler.ServeHTTP(w, r)
+	return true
+}
+
+// retire waits for in-flight requests to finish and then releases the plugins.
+func (g *generation) retire() {
+	g.mu.Lock()
+	g.retired = true
+	g.mu.Unlock()
+	if g.closer != nil {
+		g.closer()
+	}
+}
+
+// reloadableHandler serves requests from the current generation and lets it be
+// swapped atomically for a new one.
+type reloadableHandler struct {
+	current atomic.Pointer[generation]
+}
+
+// ServeHTTP dispatches the request to the current generation.
+func (h *reloadableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// A request can race with a swap and pick up a generation that is being retired;
+	// it then retries against the new one. A generation is only retired after its
+	// successor is installed, unless the server is shutting down.
+	for {
+		g := h.current.Load()
+		if g.serve(w, r) {
+			return
+		}
+		if h.current.Load() == g {
+			http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+			return
+		}
+	}
+}
+
+// swap installs g as the current generation and returns the previous one.
+func (h *reloadableHandler) swap(g *generation) *generation {
+	return h.current.Swap(g)
+}
+
+// close retires the current generation.
+func (h *reloadableHandler) close() {
+	if g := h.current.Load(); g != nil {
+		g.retire()
+	}
+}
+
+// newGeneration creates a plugin manager and the module handlers for the given configuration.
+func newGeneration(ctx context.Context, cfg *Config) (*generation, error) {
+	log.Infof(ctx, "Initializing plugin manager")
+	mgr, closer, err := newManagerFunc(ctx, cfg.PluginManager)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin manager: %w", err)
+	}
+	log.Debug(ctx, "Plugin manager loaded.")
+
+	log.Infof(ctx, "Initializing HTTP server")
+	srv, srvCloser, err := newServerFunc(ctx, mgr, cfg)
+	if err != nil {
+		closer()
+		return nil, fmt.Errorf("failed to initialize server: %w", err)
+	}
+	// The handlers are closed before the plugins they use.
+	return &generation{handler: srv, closer: func() {
+		if srvCloser != nil {
+			srvCloser()
+		}
+		closer()
+	}}, nil
+}
+
+// reload reads the configuration again and swaps in freshly built module handlers.
+// The previous generation keeps serving if the new configuration cannot be loaded.
+func (h *reloadableHandler) reload(ctx context.Context, path string, running *Config) error {
+	cfg, err := initConfig(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to reload config: %w", err)
+	}
+	if !reflect.DeepEqual(cfg.HTTP, running.HTTP) || !reflect.DeepEqual(cfg.Log, running.Log) ||
+		!reflect.DeepEqual(cfg.Tracing, running.Tracing) {
+		log.Warn(ctx, "Changes to http, log and tracing config are ignored until restart")
+	}
+	g, err := newGeneration(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("failed to reload modules: %w", err)
+	}
+	old := h.swap(g)
+	log.Infof(ctx, "Configuration reloaded from %s", path)
+	if old != nil {
+		go old.retire()
+	}
+	return nil
+}
+
+// notifyReload returns a channel that receives SIGHUP, and a function that stops
+// the delivery. It is called before the server starts so that an early SIGHUP
+// triggers a reload instead of terminating the process.
+func notifyReload() (<-chan os.Signal, func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	return hup, func() { signal.Stop(hup) }
+}
+
+// watchConfig triggers reload whenever hup receives a signal and, if interval is
+// positive, whenever the modification time or size of the config file changes.
+// It returns when ctx is done.
+func watchConfig(ctx context.Context, path string, interval time.Duration, hup <-chan os.Signal, reload func()) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	last, _ := os.Stat(path)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			log.Info(ctx, "Received SIGHUP, reloading configuration")
+			reload()
+		case <-tick:
+			fi, err := os.Stat(path)
+			if err != nil {
+				log.Errorf(ctx, err, "Failed to stat config file %s", path)
+				continue
+			}
+			if last != nil && fi.ModTime().Equal(last.ModTime()) && fi.Size() == last.Size() {
+				continue
+			}
+			last = fi
+			log.Infof(ctx, "Config file %s changed, reloading configuration", path)
+			reload()
+		}
+	}
+}
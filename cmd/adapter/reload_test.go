@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/core/module/handler"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+)
+
+const reloadTestConfig = `
+appName: "TestApp"
+http:
+  port: "8080"
+`
+
+// statusHandler returns a handler that replies with the given status code.
+func statusHandler(code int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	})
+}
+
+// mockGenerations replaces the manager and server constructors for the duration of the test.
+func mockGenerations(t *testing.T, serverFunc func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, func(), error), closed *atomic.Int32) {
+	t.Helper()
+	originalNewManager := newManagerFunc
+	newManagerFunc = func(ctx context.Context, cfg *plugin.ManagerConfig) (*plugin.Manager, func(), error) {
+		return &plugin.Manager{}, func() { closed.Add(1) }, nil
+	}
+	originalNewServer := newServerFunc
+	newServerFunc = serverFunc
+	t.Cleanup(func() {
+		newManagerFunc = originalNewManager
+		newServerFunc = originalNewServer
+	})
+}
+
+// TestReloadSuccess tests that a reload swaps in the new handlers and releases the old plugins.
+func TestReloadSuccess(t *testing.T) {
+	var closed atomic.Int32
+	mockGenerations(t, func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, func(), error) {
+		return statusHandler(http.StatusAccepted), nil, nil
+	}, &closed)
+
+	path := filepath.Join(t.TempDir(), "adapter.yaml")
+	if err := os.WriteFile(path, []byte(reloadTestConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	h := &reloadableHandler{}
+	h.swap(&generation{handler: statusHandler(http.StatusOK), closer: func() { closed.Add(1) }})
+
+	if err := h.reload(context.Background(), path, &Config{}); err != nil {
+		t.Fatalf("reload() error = %v, want nil", err)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for closed.Load() != 1 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := closed.Load(); got != 1 {
+		t.Errorf("closers run = %d, want 1", got)
+	}
+}
+
+// TestReloadFailure tests that a rejected reload keeps the current handlers.
+func TestReloadFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		configData string
+		serverErr  error
+	}{
+		{
+			name:       "invalid config",
+			configData: `appName: ""`,
+		},
+		{
+			name:       "module initialization fails",
+			configData: reloadTestConfig,
+			serverErr:  errors.New("bad module"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var closed atomic.Int32
+			mockGenerations(t, func(ctx context.Context, mgr handler.PluginManager, cfg *Config) (http.Handler, func(), error) {
+				return statusHandler(http.StatusAccepted), nil, tt.serverErr
+			}, &closed)
+
+			path := filepath.Join(t.TempDir(), "adapter.yaml")
+			if err := os.WriteFile(path, []byte(tt.configData), 0644); err != nil {
+				t.Fatalf("Failed to write config: %v", err)
+			}
+
+			h := &reloadableHandler{}
+			h.swap(&generation{handler: statusHandler(http.StatusOK)})
+
+			if err := h.reload(context.Background(), path, &Config{}); err == nil {
+				t.Fatal("reload() error = nil, want error")
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, http.StatusOK)
+			}
+			if tt.serverErr != nil && closed.Load() != 1 {
+				t.Errorf("plugin manager of the rejected generation was not closed")
+			}
+		})
+	}
+}
+
+// TestRetireWaitsForInFlight tests that plugins are released only after in-flight requests finish.
+func TestRetireWaitsForInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var closed atomic.Bool
+
+	g := &generation{
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+			if closed.Load() {
+				t.Error("plugins closed while a request was in flight")
+			}
+		}),
+		closer: func() { closed.Store(true) },
+	}
+	h := &reloadableHandler{}
+	h.swap(g)
+
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+		close(done)
+	}()
+	<-started
+
+	h.swap(&generation{handler: statusHandler(http.StatusOK)})
+	retired := make(chan struct{})
+	go func() {
+		g.retire()
+		close(retired)
+	}()
+
+	select {
+	case <-retired:
+		t.Fatal("retire() returned before the in-flight request finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-done
+	<-retired
+	if !closed.Load() {
+		t.Error("retire() did not run the closer")
+	}
+
+	// Requests arriving after the swap are served by the new generation.
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+// TestWatchConfig tests that a change to the config file triggers a reload.
+func TestWatchConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "adapter.yaml")
+	if err := os.WriteFile(path, []byte(reloadTestConfig), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reloaded := make(chan struct{}, 1)
+	go watchConfig(ctx, path, 10*time.Millisecond, nil, func() {
+		select {
+		case reloaded <- struct{}{}:
+		default:
+		}
+	})
+
+	// Give the watcher time to record the initial state before changing the file.
+	time.Sleep(30 * time.Millisecond)
+	if err := os.WriteFile(path, []byte(reloadTestConfig+"\nreload:\n  watchInterval: 1s\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchConfig() did not trigger a reload after the file changed")
+	}
+}
+
+// TestWatchConfigSignal tests that a signal on the hup channel triggers a reload.
+func TestWatchConfigSignal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hup := make(chan os.Signal, 1)
+	reloaded := make(chan struct{}, 1)
+	go watchConfig(ctx, filepath.Join(t.TempDir(), "adapter.yaml"), 0, hup, func() {
+		reloaded <- struct{}{}
+	})
+
+	hup <- syscall.SIGHUP
+	select {
+	case <-reloaded:
+	case <-time.After(2 * time.Second):
+		t.Fatal("watchConfig() did not trigger a reload on SIGHUP")
+	}
+}
+
+// TestServeAfterClose tests that requests get a 503 once the last generation is retired.
+func TestServeAfterClose(t *testing.T) {
+	h := &reloadableHandler{}
+	h.swap(&generation{handler: statusHandler(http.StatusOK)})
+	h.close()
+
+	done := make(chan int)
+	go func() {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		done <- rec.Code
+	}()
+	select {
+	case code := <-done:
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("ServeHTTP() status = %d, want %d", code, http.StatusServiceUnavailable)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("ServeHTTP() did not return after close()")
+	}
+}
@@ -0,0 +1,96 @@
+//go:build staticplugins
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestStaticPlugins tests that the adapter serves a request end to end with the
+// plugins linked into the binary and no plugin root to load .so files from.
+func TestStaticPlugins(t *testing.T) {
+	received := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"message":{"ack":{"status":"ACK"}}}`))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	rules := filepath.Join(dir, "routing.yaml")
+	writeFile(t, rules, `routingRules:
+  - domain: "retail:1.1.0"
+    version: "1.1.0"
+    targetType: "url"
+    target:
+      url: "`+upstream.URL+`/bpp"
+    endpoints:
+      - search
+`)
+	cfgPath := filepath.Join(dir, "config.yaml")
+	writeFile(t, cfgPath, `appName: "onix-static"
+http:
+  port: 8080
+pluginManager:
+  root: ""
+modules:
+  - name: bapTxnCaller
+    path: /bap/caller/
+    handler:
+      type: std
+      role: bap
+      plugins:
+        router:
+          id: router
+          config:
+            routingConfig: `+rules+`
+      steps:
+        - addRoute
+`)
+
+	ctx := context.Background()
+	cfg, err := initConfig(ctx, cfgPath)
+	if err != nil {
+		t.Fatalf("initConfig() error = %v", err)
+	}
+	g, err := newGeneration(ctx, cfg)
+	if err != nil {
+		t.Fatalf("newGeneration() error = %v", err)
+	}
+	defer g.retire()
+
+	body := `{"context":{"domain":"retail:1.1.0","version":"1.1.0","action":"search","transaction_id":"txn-1","message_id":"msg-1"}}`
+	req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	if !g.serve(rec, req) {
+		t.Fatal("serve() = false, want true")
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	select {
+	case got := <-received:
+		if got != body {
+			t.Errorf("upstream received %s, want %s", got, body)
+		}
+	default:
+		t.Error("request was not forwarded upstream")
+	}
+}
+
+// writeFile writes content to path or fails the test.
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
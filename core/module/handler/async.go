@@ -0,0 +1,259 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
+)
+
+// Default values for asynchronous delivery, used when the config leaves them unset.
+const (
+	defaultAsyncWorkers     = 4
+	defaultAsyncQueueSize   = 100
+	defaultAsyncMaxAttempts = 3
+	defaultAsyncBackoff     = time.Second
+)
+
+// errQueueFull is returned when a request cannot be queued for asynchronous delivery.
+var errQueueFull = errors.New("async delivery queue is full")
+
+// errDispatcherClosed is returned when a request is queued after the dispatcher was closed.
+var errDispatcherClosed = errors.New("async delivery is shut down")
+
+// delivery is a routed request waiting to be delivered in the background.
+type delivery struct {
+	ctx    context.Context
+	route  *model.Route
+	method string
+	header http.Header
+	body   []byte
+	txnID  string
+	msgID  string
+}
+
+// asyncDispatcher delivers routed requests in the background using a bounded pool of workers.
+// It retries failed deliveries itself, so its requests bypass the retries of the HTTP client.
+type asyncDispatcher struct {
+	queue       chan *delivery
+	client      *http.Client
+	publisher   definition.Publisher
+	maxAttempts int
+	backoff     time.Duration
+
+	mu      sync.Mutex // mu guards closed and serializes enqueues.
+	closed  bool
+	closing chan struct{} // closing is closed when the dispatcher starts shutting down.
+	workers sync.WaitGroup
+}
+
+// newAsyncDispatcher creates a dispatcher and starts its workers. The workers run until
+// the dispatcher is closed.
+func newAsyncDispatcher(ctx context.Context, cfg *AsyncConfig, client *http.Client, pb definition.Publisher) *asyncDispatcher {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = defaultAsyncWorkers
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultAsyncQueueSize
+	}
+	d := &asyncDispatcher{
+		queue:       make(chan *delivery, queueSize),
+		client:      client,
+		publisher:   pb,
+		maxAttempts: cfg.MaxAttempts,
+		backoff:     cfg.Backoff,
+		closing:     make(chan struct{}),
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = defaultAsyncMaxAttempts
+	}
+	if d.backoff <= 0 {
+		d.backoff = defaultAsyncBackoff
+	}
+	d.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go d.work()
+	}
+	log.Infof(ctx, "Async delivery enabled with %d workers, queue size %d", workers, queueSize)
+	return d
+}
+
+// enqueue queues the routed request for delivery. It fails if the route cannot be
+// delivered or the queue is full, so that the caller can NACK instead of ACK.
+// A broadcast route is queued as one delivery per URL, and only if all of them fit,
+// so that a NACKed broadcast has not been delivered to any URL.
+func (d *asyncDispatcher) enqueue(ctx *model.StepContext, r *http.Request) error {
+	routes := []*model.Route{ctx.Route}
+	switch ctx.Route.TargetType {
+	case "url":
+	case "publisher":
+		if d.publisher == nil {
+			return fmt.Errorf("publisher plugin not configured")
+		}
+	case "broadcast":
+		routes = make([]*model.Route, 0, len(ctx.Route.URLs))
+		for _, u := range ctx.Route.URLs {
+			routes = append(routes, &model.Route{TargetType: "url", URL: u})
+		}
+	default:
+		return fmt.Errorf("unknown route type: %s", ctx.Route.TargetType)
+	}
+
+	header := r.Header.Clone()
+	header.Set("X-Forwarded-Host", r.Host)
+	txnID, msgID := messageIDs(ctx, ctx.Body)
+	// The body buffer is reused once the request completes.
+	body := bytes.Clone(ctx.Body)
+	// The delivery outlives the request, so it must not be cancelled with it.
+	dctx := withoutRetries(context.WithoutCancel(ctx.Context))
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.closed {
+		return errDispatcherClosed
+	}
+	// Workers only take from the queue, so the room checked here cannot shrink.
+	if cap(d.queue)-len(d.queue) < len(routes) {
+		return errQueueFull
+	}
+	for _, rt := range routes {
+		d.queue <- &delivery{
+			ctx:    dctx,
+			route:  rt,
+			method: r.Method,
+			header: header,
+			body:   body,
+			txnID:  txnID,
+			msgID:  msgID,
+		}
+	}
+	return nil
+}
+
+// close stops accepting deliveries and returns once the queued ones have been
+// delivered. Failed deliveries are no longer retried while the dispatcher is closing.
+func (d *asyncDispatcher) close() {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	d.closed = true
+	close(d.closing)
+	close(d.queue)
+	d.mu.Unlock()
+	d.workers.Wait()
+}
+
+// work delivers queued requests until the dispatcher is closed and its queue is empty.
+func (d *asyncDispatcher) work() {
+	defer d.workers.Done()
+	for dl := range d.queue {
+		d.deliver(dl)
+	}
+}
+
+// deliver sends the request to its route, retrying failures with exponential backoff.
+func (d *asyncDispatcher) deliver(dl *delivery) {
+	var err error
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		var retry bool
+		if retry, err = d.send(dl); err == nil {
+			log.Infof(dl.ctx, "Async delivery succeeded on attempt %d, transaction_id: %s, message_id: %s", attempt, dl.txnID, dl.msgID)
+			return
+		}
+		if !retry || attempt == d.maxAttempts {
+			break
+		}
+		log.Warnf(dl.ctx, "Async delivery attempt %d failed, retrying: %v", attempt, err)
+		select {
+		case <-d.closing:
+			log.Errorf(dl.ctx, err, "Async delivery abandoned at shutdown, transaction_id: %s, message_id: %s", dl.txnID, dl.msgID)
+			return
+		case <-time.After(d.backoff << (attempt - 1)):
+		}
+	}
+	log.Errorf(dl.ctx, err, "Async delivery failed, transaction_id: %s, message_id: %s", dl.txnID, dl.msgID)
+}
+
+// send makes a single delivery attempt and reports whether a failure is worth retrying.
+func (d *asyncDispatcher) send(dl *delivery) (bool, error) {
+	if dl.route.TargetType == "publisher" {
+		log.Infof(dl.ctx, "Publishing message to: %s", dl.route.PublisherID)
+		if err := publish(dl.ctx, d.publisher, dl.route.PublisherID, dl.body); err != nil {
+			return true, fmt.Errorf("failed to publish message: %w", err)
+		}
+		return false, nil
+	}
+
+	req, err := http.NewRequestWithContext(dl.ctx, dl.method, dl.route.URL.String(), bytes.NewReader(dl.body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = dl.header.Clone()
+
+	spanCtx, span := tracing.Start(dl.ctx, "proxy "+dl.route.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("url.full", dl.route.URL.String())))
+	req = req.WithContext(spanCtx)
+	tracing.Inject(spanCtx, propagation.HeaderCarrier(req.Header))
+	retry, err := d.forward(req, dl)
+	tracing.End(span, err)
+	return retry, err
+}
+
+// forward sends the request for a url route and classifies the outcome.
+func (d *asyncDispatcher) forward(req *http.Request, dl *delivery) (bool, error) {
+	log.Request(dl.ctx, req, dl.body)
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	if err != nil {
+		metrics.ObserveOutbound(dl.route.URL.Host, 0, time.Since(start))
+		return true, fmt.Errorf("failed to forward request to %s: %w", dl.route.URL, err)
+	}
+	metrics.ObserveOutbound(dl.route.URL.Host, resp.StatusCode, time.Since(start))
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		retry := resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, dl.route.URL)
+	}
+	return false, nil
+}
+
+// messageIDs returns the transaction and message IDs of the request, preferring the
+// values set on the context by the request preprocessor over the ones in the body.
+func messageIDs(ctx context.Context, body []byte) (string, string) {
+	txnID, _ := ctx.Value(model.ContextKeyTxnID).(string)
+	msgID, _ := ctx.Value(model.ContextKeyMsgID).(string)
+	if txnID != "" && msgID != "" {
+		return txnID, msgID
+	}
+	bc, err := model.BecknContextFor(ctx, body)
+	if err != nil {
+		return txnID, msgID
+	}
+	if txnID == "" {
+		txnID = bc.TransactionID
+	}
+	if msgID == "" {
+		msgID = bc.MessageID
+	}
+	return txnID, msgID
+}
@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+const asyncTestBody = `{"context":{"action":"search","transaction_id":"txn-1","message_id":"msg-1"}}`
+
+// routeStep is a step that sets a fixed route on the step context.
+type routeStep struct {
+	route *model.Route
+}
+
+// Run sets the configured route.
+func (s *routeStep) Run(ctx *model.StepContext) error {
+	ctx.Route = s.route
+	return nil
+}
+
+// ackStatus decodes the ACK status from a recorded response.
+func ackStatus(t *testing.T, rec *httptest.ResponseRecorder) model.Status {
+	t.Helper()
+	var resp model.Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	return resp.Message.Ack.Status
+}
+
+// TestAsyncDeliverySuccess tests that the caller is ACKed and the request is delivered with retries.
+func TestAsyncDeliverySuccess(t *testing.T) {
+	var calls atomic.Int32
+	received := make(chan http.Header, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received <- r.Header
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL + "/search")
+	client := newHTTPClient(&HttpClientConfig{})
+	h := &stdHandler{
+		steps:      nil,
+		httpClient: client,
+		async: newAsyncDispatcher(context.Background(), &AsyncConfig{
+			Enabled:     true,
+			Workers:     1,
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+		}, client, nil),
+	}
+	h.steps = append(h.steps, &routeStep{route: &model.Route{TargetType: "url", URL: target}})
+
+	req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", bytes.NewBufferString(asyncTestBody))
+	req.Header.Set("Authorization", "Signature keyId=\"bap|k1|ed25519\"")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || ackStatus(t, rec) != model.StatusACK {
+		t.Fatalf("ServeHTTP() = %d %s, want 200 ACK", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case header := <-received:
+		if got := header.Get("Authorization"); got != req.Header.Get("Authorization") {
+			t.Errorf("forwarded Authorization = %q, want %q", got, req.Header.Get("Authorization"))
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request was not delivered")
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("delivery attempts = %d, want 2", got)
+	}
+}
+
+// TestAsyncDeliveryNoRetryOnClientError tests that a 4xx response is not retried.
+func TestAsyncDeliveryNoRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	d := &asyncDispatcher{client: srv.Client(), maxAttempts: 3, backoff: time.Millisecond}
+	d.deliver(&delivery{
+		ctx:    context.Background(),
+		route:  &model.Route{TargetType: "url", URL: target},
+		method: http.MethodPost,
+		header: http.Header{},
+		body:   []byte(asyncTestBody),
+	})
+	if got := calls.Load(); got != 1 {
+		t.Errorf("delivery attempts = %d, want 1", got)
+	}
+}
+
+// TestAsyncEnqueueFailure tests that requests that cannot be queued are NACKed.
+func TestAsyncEnqueueFailure(t *testing.T) {
+	target, _ := url.Parse("http://localhost/search")
+	tests := []struct {
+		name   string
+		route  *model.Route
+		queue  chan *delivery
+		closed bool
+	}{
+		{
+			name:  "queue full",
+			route: &model.Route{TargetType: "url", URL: target},
+			queue: make(chan *delivery),
+		},
+		{
+			name:  "broadcast does not fit",
+			route: &model.Route{TargetType: "broadcast", URLs: []*url.URL{target, target}},
+			queue: make(chan *delivery, 1),
+		},
+		{
+			name:   "dispatcher closed",
+			route:  &model.Route{TargetType: "url", URL: target},
+			queue:  make(chan *delivery, 1),
+			closed: true,
+		},
+		{
+			name:  "publisher not configured",
+			route: &model.Route{TargetType: "publisher", PublisherID: "topic"},
+			queue: make(chan *delivery, 1),
+		},
+		{
+			name:  "unknown route type",
+			route: &model.Route{TargetType: "unknown"},
+			queue: make(chan *delivery, 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &stdHandler{
+				async: &asyncDispatcher{queue: tt.queue, maxAttempts: 1, closed: tt.closed},
+			}
+			h.steps = append(h.steps, &routeStep{route: tt.route})
+
+			req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", bytes.NewBufferString(asyncTestBody))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if ackStatus(t, rec) != model.StatusNACK {
+				t.Errorf("ServeHTTP() = %s, want NACK", rec.Body.String())
+			}
+			if n := len(tt.queue); n != 0 {
+				t.Errorf("queued deliveries = %d for a NACKed request, want 0", n)
+			}
+		})
+	}
+}
+
+// TestAsyncDispatcherClose tests that closing the dispatcher delivers the queued
+// requests before returning and rejects new ones.
+func TestAsyncDispatcherClose(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	d := newAsyncDispatcher(context.Background(), &AsyncConfig{Workers: 1, QueueSize: 3}, srv.Client(), nil)
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", nil)
+		ctx := &model.StepContext{Context: req.Context(), Body: []byte(asyncTestBody), Route: &model.Route{TargetType: "url", URL: target}}
+		if err := d.enqueue(ctx, req); err != nil {
+			t.Fatalf("enqueue() error = %v", err)
+		}
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		d.close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("close() returned before the queued requests were delivered")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	<-closed
+	if got := calls.Load(); got != 3 {
+		t.Errorf("deliveries = %d, want 3", got)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", nil)
+	ctx := &model.StepContext{Context: req.Context(), Body: []byte(asyncTestBody), Route: &model.Route{TargetType: "url", URL: target}}
+	if err := d.enqueue(ctx, req); !errors.Is(err, errDispatcherClosed) {
+		t.Errorf("enqueue() after close error = %v, want %v", err, errDispatcherClosed)
+	}
+}
+
+// TestAsyncDeliveryClientRetries tests that the retries of the HTTP client do not
+// add to the retries of the dispatcher.
+func TestAsyncDeliveryClientRetries(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	target, _ := url.Parse(srv.URL)
+	client := newHTTPClient(&HttpClientConfig{Retry: RetryConfig{MaxAttempts: 3, Backoff: time.Millisecond}})
+	d := newAsyncDispatcher(context.Background(), &AsyncConfig{Workers: 1, MaxAttempts: 2, Backoff: time.Millisecond}, client, nil)
+	req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", nil)
+	ctx := &model.StepContext{Context: req.Context(), Body: []byte(asyncTestBody), Route: &model.Route{TargetType: "url", URL: target}}
+	if err := d.enqueue(ctx, req); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	// Let the first attempt fail before closing, so that the retry is not skipped.
+	time.Sleep(50 * time.Millisecond)
+	d.close()
+	if got := calls.Load(); got != 2 {
+		t.Errorf("delivery attempts = %d, want 2", got)
+	}
+}
+
+// TestMessageIDs tests extraction of transaction and message IDs.
+func TestMessageIDs(t *testing.T) {
+	ctx := context.WithValue(context.Background(), model.ContextKeyTxnID, "ctx-txn")
+
+	txnID, msgID := messageIDs(ctx, []byte(asyncTestBody))
+	if txnID != "ctx-txn" || msgID != "msg-1" {
+		t.Errorf("messageIDs() = %q, %q, want %q, %q", txnID, msgID, "ctx-txn", "msg-1")
+	}
+
+	txnID, msgID = messageIDs(context.Background(), []byte("not json"))
+	if txnID != "" || msgID != "" {
+		t.Errorf("messageIDs() = %q, %q, want empty IDs", txnID, msgID)
+	}
+}
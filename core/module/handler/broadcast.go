@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/response"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
+)
+
+// destinationResult is the outcome of delivering a broadcast request to one destination.
+type destinationResult struct {
+	url    *url.URL
+	status int
+	err    error
+}
+
+// broadcast delivers the request to every URL of the route concurrently and logs
+// the outcome for each destination. The caller is ACKed according to policy: for
+// AckPolicyAny as soon as one destination accepts the request, while the other
+// deliveries complete in the background. It returns the status of the first
+// destination that accepted the request, or of the first that responded if none
+// did, and the error with which the caller was NACKed. The status is 0 for
+// AckPolicyNone, whose deliveries all complete in the background.
+func broadcast(ctx *model.StepContext, r *http.Request, w http.ResponseWriter, httpClient *http.Client, policy AckPolicy) (int, error) {
+	header := r.Header.Clone()
+	header.Set("X-Forwarded-Host", r.Host)
+	// The transports may still be writing the body after the request completes, so
+	// the deliveries need their own copy of it, as the buffer is reused.
+	body := bytes.Clone(ctx.Body)
+
+	if policy == AckPolicyNone {
+		// The deliveries outlive the request, so they must not be cancelled with it.
+		deliverAll(context.WithoutCancel(ctx.Context), httpClient, r.Method, ctx.Route.URLs, header, body)
+		response.SendAck(w)
+		return 0, nil
+	}
+
+	var deliveryCtx context.Context = ctx
+	if policy == AckPolicyAny {
+		// The deliveries still running after the first acceptance outlive the request.
+		deliveryCtx = context.WithoutCancel(ctx.Context)
+	}
+	accepted, status := 0, 0
+	for res := range deliverAll(deliveryCtx, httpClient, r.Method, ctx.Route.URLs, header, body) {
+		if res.err == nil {
+			if accepted == 0 {
+				status = res.status
+			}
+			accepted++
+			if policy == AckPolicyAny {
+				break
+			}
+		} else if status == 0 {
+			status = res.status
+		}
+	}
+	total := len(ctx.Route.URLs)
+	if (policy == AckPolicyAll && accepted < total) || accepted == 0 {
+		err := model.NewUnavailableErr(fmt.Errorf("request was accepted by %d of %d destinations, ack policy %s", accepted, total, policy))
+		response.SendNack(ctx, w, err)
+		return status, err
+	}
+	response.SendAck(w)
+	return status, nil
+}
+
+// deliverAll sends the request to all targets concurrently and logs the outcome for
+// each target. It returns a channel on which the outcomes are sent as they complete,
+// which is closed once all have. The channel has room for every outcome, so the
+// deliveries complete whether or not it is read.
+func deliverAll(ctx context.Context, httpClient *http.Client, method string, targets []*url.URL, header http.Header, body []byte) <-chan destinationResult {
+	results := make(chan destinationResult, len(targets))
+	var wg sync.WaitGroup
+	var accepted atomic.Int32
+	for _, target := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, err := send(ctx, httpClient, method, target, header, body)
+			if err != nil {
+				log.Errorf(ctx, err, "Broadcast to %s failed", target)
+			} else {
+				accepted.Add(1)
+				log.Infof(ctx, "Broadcast to %s succeeded with status %d", target, status)
+			}
+			results <- destinationResult{url: target, status: status, err: err}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		log.Infof(ctx, "Broadcast accepted by %d of %d destinations", accepted.Load(), len(targets))
+		close(results)
+	}()
+	return results
+}
+
+// send makes a single request to target in a client span and returns the response status.
+// Responses other than 2xx are reported as errors.
+func send(ctx context.Context, httpClient *http.Client, method string, target *url.URL, header http.Header, body []byte) (int, error) {
+	spanCtx, span := tracing.Start(ctx, "proxy "+target.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("url.full", target.String())))
+	var err error
+	defer func() { tracing.End(span, err) }()
+
+	req, err := http.NewRequestWithContext(spanCtx, method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = header.Clone()
+	tracing.Inject(spanCtx, propagation.HeaderCarrier(req.Header))
+	log.Request(spanCtx, req, body)
+
+	start := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		metrics.ObserveOutbound(target.Host, 0, time.Since(start))
+		err = fmt.Errorf("failed to forward request to %s: %w", target, err)
+		return 0, err
+	}
+	metrics.ObserveOutbound(target.Host, resp.StatusCode, time.Since(start))
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		err = fmt.Errorf("unexpected status %d from %s", resp.StatusCode, target)
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// TestBroadcast tests that a broadcast route is delivered to every destination with the original headers.
+func TestBroadcast(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   AckPolicy
+		statuses []int
+		wantCode int
+		wantAck  model.Status
+	}{
+		{name: "any, all accepted", policy: AckPolicyAny, statuses: []int{http.StatusOK, http.StatusOK}, wantCode: http.StatusOK, wantAck: model.StatusACK},
+		{name: "any, one accepted", policy: AckPolicyAny, statuses: []int{http.StatusOK, http.StatusInternalServerError}, wantCode: http.StatusOK, wantAck: model.StatusACK},
+		{name: "any, none accepted", policy: AckPolicyAny, statuses: []int{http.StatusBadRequest, http.StatusInternalServerError}, wantCode: http.StatusServiceUnavailable, wantAck: model.StatusNACK},
+		{name: "all, all accepted", policy: AckPolicyAll, statuses: []int{http.StatusOK, http.StatusOK}, wantCode: http.StatusOK, wantAck: model.StatusACK},
+		{name: "all, one accepted", policy: AckPolicyAll, statuses: []int{http.StatusOK, http.StatusInternalServerError}, wantCode: http.StatusServiceUnavailable, wantAck: model.StatusNACK},
+		{name: "none, none accepted", policy: AckPolicyNone, statuses: []int{http.StatusBadRequest, http.StatusInternalServerError}, wantCode: http.StatusOK, wantAck: model.StatusACK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan http.Header, len(tt.statuses))
+			var targets []*url.URL
+			for _, status := range tt.statuses {
+				srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					received <- r.Header
+					w.WriteHeader(status)
+				}))
+				defer srv.Close()
+				target, _ := url.Parse(srv.URL + "/search")
+				targets = append(targets, target)
+			}
+
+			h := &stdHandler{httpClient: newHTTPClient(&HttpClientConfig{}), ackPolicy: tt.policy}
+			h.steps = append(h.steps, &routeStep{route: &model.Route{TargetType: "broadcast", URLs: targets}})
+			req := httptest.NewRequest(http.MethodPost, "/bg/receiver/search", bytes.NewBufferString(asyncTestBody))
+			req.Header.Set(model.AuthHeaderSubscriber, "bap-signature")
+			req.Header.Set(model.AuthHeaderGateway, "gateway-signature")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantCode || ackStatus(t, rec) != tt.wantAck {
+				t.Fatalf("ServeHTTP() = %d %s, want %d %s", rec.Code, rec.Body.String(), tt.wantCode, tt.wantAck)
+			}
+			for range tt.statuses {
+				var header http.Header
+				select {
+				case header = <-received:
+				case <-time.After(2 * time.Second):
+					t.Fatal("request was not delivered to every destination")
+				}
+				if header.Get(model.AuthHeaderSubscriber) != "bap-signature" || header.Get(model.AuthHeaderGateway) != "gateway-signature" {
+					t.Errorf("forwarded signatures = %q, %q, want both originals", header.Get(model.AuthHeaderSubscriber), header.Get(model.AuthHeaderGateway))
+				}
+			}
+		})
+	}
+}
+
+// TestBroadcastAnyDoesNotWait tests that under AckPolicyAny the caller is ACKed once
+// one destination accepts the request, and that slower deliveries still complete
+// after the request is done.
+func TestBroadcastAnyDoesNotWait(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fast.Close()
+	release := make(chan struct{})
+	received := make(chan string, 1)
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+	fastURL, _ := url.Parse(fast.URL + "/search")
+	slowURL, _ := url.Parse(slow.URL + "/search")
+
+	h := &stdHandler{httpClient: newHTTPClient(&HttpClientConfig{}), ackPolicy: AckPolicyAny}
+	h.steps = append(h.steps, &routeStep{route: &model.Route{TargetType: "broadcast", URLs: []*url.URL{slowURL, fastURL}}})
+	reqCtx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest(http.MethodPost, "/bg/receiver/search", bytes.NewBufferString(asyncTestBody)).WithContext(reqCtx)
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(rec, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		close(release)
+		t.Fatal("ServeHTTP() waited for the slow destination")
+	}
+	if rec.Code != http.StatusOK || ackStatus(t, rec) != model.StatusACK {
+		t.Fatalf("ServeHTTP() = %d %s, want 200 ACK", rec.Code, rec.Body.String())
+	}
+
+	cancel()
+	close(release)
+	select {
+	case body := <-received:
+		if body != asyncTestBody {
+			t.Errorf("slow destination received %q, want %q", body, asyncTestBody)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request was not delivered to the slow destination")
+	}
+}
+
+// TestNewStdHandlerInvalidAckPolicy tests that an unknown broadcast ACK policy is rejected.
+func TestNewStdHandlerInvalidAckPolicy(t *testing.T) {
+	cfg := &Config{Broadcast: BroadcastConfig{AckPolicy: "most"}}
+	if _, err := NewStdHandler(context.Background(), nil, cfg); err == nil {
+		t.Fatal("NewStdHandler() error = nil, want error for invalid ackPolicy")
+	}
+}
@@ -0,0 +1,197 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+const (
+	// callbackKeyPrefix namespaces the expected callbacks in the cache.
+	callbackKeyPrefix = "callback:"
+
+	// defaultCallbackTTL is how long a callback is expected for requests without a
+	// valid context.ttl if no TTL is configured.
+	defaultCallbackTTL = 30 * time.Second
+
+	// callbackLateWindow is how long an expected callback is kept after its deadline,
+	// so that callbacks arriving within it are reported as late rather than unsolicited.
+	callbackLateWindow = 10 * time.Minute
+)
+
+// defaultUnsolicited are the callbacks accepted without a request if none are
+// configured: those that a BPP sends on its own when a confirmed order changes.
+var defaultUnsolicited = []string{"on_status", "on_update", "on_cancel", "on_track"}
+
+// callbackKey returns the cache key of the callback expected for the request of
+// action with msgID sent by bapID to bppID. bppID is empty for requests that any
+// BPP may answer, such as a search sent through a gateway.
+func callbackKey(bapID, bppID, action, msgID string) string {
+	return callbackKeyPrefix + bapID + "|" + bppID + "|" + action + "|" + msgID
+}
+
+// expectCallbackStep records the requests sent by a caller module, so that the
+// validateCallback step of its receiver module can correlate their callbacks.
+type expectCallbackStep struct {
+	cache definition.Cache
+	ttl   time.Duration
+	now   func() time.Time
+}
+
+// newExpectCallbackStep creates and returns the expectCallback step.
+func newExpectCallbackStep(cache definition.Cache, cfg *CallbackConfig) (*expectCallbackStep, error) {
+	if cache == nil {
+		return nil, fmt.Errorf("invalid config: expectCallback step requires the Cache plugin")
+	}
+	s := &expectCallbackStep{cache: cache, ttl: cfg.TTL, now: time.Now}
+	if s.ttl <= 0 {
+		s.ttl = defaultCallbackTTL
+	}
+	return s, nil
+}
+
+// Run records the deadline by which the callback of the request is expected, which
+// is the context.ttl of the request from now. If the cache fails, the request is sent,
+// and validateCallback accepts its callback if the cache is still failing then.
+func (s *expectCallbackStep) Run(ctx *model.StepContext) error {
+	bc, err := ctx.BecknContext()
+	if err != nil || bc.MessageID == "" {
+		log.Debugf(ctx, "No message_id, not expecting a callback")
+		return nil
+	}
+	action := conditionContext(ctx).Action
+	if strings.HasPrefix(action, "on_") {
+		return nil
+	}
+	ttl := s.ttl
+	if bc.TTL != "" {
+		if d, err := parseISODuration(bc.TTL); err == nil && d > 0 {
+			ttl = d
+		} else {
+			log.Warnf(ctx, "Invalid context.ttl %q, expecting the callback within %s", bc.TTL, s.ttl)
+		}
+	}
+	deadline := s.now().Add(ttl)
+	key := callbackKey(bc.BAPID, bc.BPPID, action, bc.MessageID)
+	if err := s.cache.Set(ctx, key, strconv.FormatInt(deadline.UnixMilli(), 10), ttl+callbackLateWindow); err != nil {
+		log.Errorf(ctx, err, "Failed to record expected callback for message_id %s", bc.MessageID)
+	}
+	return nil
+}
+
+// validateCallbackStep checks that a callback received by a receiver module answers
+// a request that its caller module sent to the same BPP, and that it arrives in time.
+type validateCallbackStep struct {
+	cache       definition.Cache
+	mode        CheckMode
+	grace       time.Duration
+	unsolicited []string
+	now         func() time.Time
+}
+
+// newValidateCallbackStep creates and returns the validateCallback step.
+func newValidateCallbackStep(cache definition.Cache, cfg *CallbackConfig) (*validateCallbackStep, error) {
+	if cache == nil {
+		return nil, fmt.Errorf("invalid config: validateCallback step requires the Cache plugin")
+	}
+	s := &validateCallbackStep{cache: cache, mode: cfg.Mode, grace: cfg.Grace, unsolicited: cfg.Unsolicited, now: time.Now}
+	if s.unsolicited == nil {
+		s.unsolicited = defaultUnsolicited
+	}
+	if s.mode == "" {
+		s.mode = CheckEnforce
+	}
+	if err := validateCheckMode(s.mode); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Run checks the callback against the expected callbacks. In warn mode an
+// unsolicited or late callback is logged and processed.
+func (s *validateCallbackStep) Run(ctx *model.StepContext) error {
+	if s.mode == CheckOff {
+		return nil
+	}
+	bc := conditionContext(ctx)
+	req, ok := strings.CutPrefix(bc.Action, "on_")
+	if !ok || slices.Contains(s.unsolicited, bc.Action) {
+		return nil
+	}
+
+	reason, err := s.check(ctx, &bc, req)
+	if err == nil {
+		return nil
+	}
+	metrics.ObserveUnexpectedCallback(ctx, bc.Action, reason)
+	if s.mode == CheckEnforce {
+		log.Warnf(ctx, "Callback rejected: %v", err)
+		return model.NewBadReqErr(err)
+	}
+	log.Warnf(ctx, "Unexpected callback: %v", err)
+	return nil
+}
+
+// check returns the reason, unsolicited or late, for which the callback is unexpected.
+// The callback must be signed by its context.bpp_id if the validateSign step validated
+// its signature, since the sender controls the body. If the cache fails, the callback
+// is accepted.
+func (s *validateCallbackStep) check(ctx *model.StepContext, bc *model.BecknContext, req string) (string, error) {
+	if id := signer(ctx); id != "" && id != bc.BPPID {
+		return "unsolicited", fmt.Errorf("unsolicited %s from %s: signed by %s", bc.Action, bc.BPPID, id)
+	}
+	value, err := s.cache.Get(ctx, callbackKey(bc.BAPID, bc.BPPID, req, bc.MessageID))
+	if errors.Is(err, definition.ErrCacheMiss) {
+		// The request may have been sent without a bpp_id, to be answered by any BPP.
+		value, err = s.cache.Get(ctx, callbackKey(bc.BAPID, "", req, bc.MessageID))
+	}
+	if errors.Is(err, definition.ErrCacheMiss) {
+		return "unsolicited", fmt.Errorf("unsolicited %s from %s: no %s with message_id %s was sent to it", bc.Action, bc.BPPID, req, bc.MessageID)
+	}
+	if err != nil {
+		log.Errorf(ctx, err, "Failed to look up expected callback for message_id %s, accepting callback", bc.MessageID)
+		return "", nil
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Errorf(ctx, err, "Invalid expected callback for message_id %s, accepting callback", bc.MessageID)
+		return "", nil
+	}
+	deadline := time.UnixMilli(ms)
+	if s.now().After(deadline.Add(s.grace)) {
+		return "late", fmt.Errorf("late %s from %s for message_id %s: expected by %s", bc.Action, bc.BPPID, bc.MessageID, deadline.UTC().Format(time.RFC3339))
+	}
+	return "", nil
+}
+
+// isoDuration matches the ISO 8601 durations used for context.ttl, such as PT30S.
+var isoDuration = regexp.MustCompile(`^P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseISODuration parses an ISO 8601 duration of weeks, days, hours, minutes and seconds.
+func parseISODuration(s string) (time.Duration, error) {
+	m := isoDuration.FindStringSubmatch(s)
+	if m == nil || s == "P" || strings.HasSuffix(s, "T") {
+		return 0, fmt.Errorf("invalid ISO 8601 duration %q", s)
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+1] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[i+1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid ISO 8601 duration %q: %w", s, err)
+		}
+		d += time.Duration(v * float64(unit))
+	}
+	return d, nil
+}
@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// callbackStepCtx returns the StepContext of a message with the given context fields.
+func callbackStepCtx(action, bppID, msgID, ttl string) *model.StepContext {
+	body := `{"context":{"action":"` + action + `","bap_id":"bap.example.com","bpp_id":"` + bppID + `","message_id":"` + msgID + `","ttl":"` + ttl + `"}}`
+	req := httptest.NewRequest(http.MethodPost, "/"+action, strings.NewReader(body))
+	return &model.StepContext{Context: req.Context(), Request: req, Body: []byte(body)}
+}
+
+// TestCallbackCorrelation tests that callbacks are checked against the requests sent.
+func TestCallbackCorrelation(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      CallbackConfig
+		request  *model.StepContext
+		callback *model.StepContext
+		signer   string
+		after    time.Duration
+		wantErr  string
+	}{
+		{
+			name:     "expected",
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_select", "bpp.example.com", "msg-1", ""),
+			after:    10 * time.Second,
+		},
+		{
+			name:     "search answered by any bpp",
+			request:  callbackStepCtx("search", "", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_search", "bpp.example.com", "msg-1", ""),
+		},
+		{
+			name:     "other bpp",
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_select", "other.example.com", "msg-1", ""),
+			wantErr:  "unsolicited on_select from other.example.com",
+		},
+		{
+			name:     "signed by bpp_id",
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_select", "bpp.example.com", "msg-1", ""),
+			signer:   "bpp.example.com",
+		},
+		{
+			name:     "signed by other bpp",
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_select", "bpp.example.com", "msg-1", ""),
+			signer:   "other.example.com",
+			wantErr:  "unsolicited on_select from bpp.example.com: signed by other.example.com",
+		},
+		{
+			name:     "other message_id",
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_select", "bpp.example.com", "msg-2", ""),
+			wantErr:  "no select with message_id msg-2",
+		},
+		{
+			name:     "other action",
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_init", "bpp.example.com", "msg-1", ""),
+			wantErr:  "unsolicited on_init",
+		},
+		{
+			name:     "late",
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_select", "bpp.example.com", "msg-1", ""),
+			after:    time.Minute,
+			wantErr:  "late on_select from bpp.example.com",
+		},
+		{
+			name:     "within grace",
+			cfg:      CallbackConfig{Grace: time.Minute},
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S"),
+			callback: callbackStepCtx("on_select", "bpp.example.com", "msg-1", ""),
+			after:    time.Minute,
+		},
+		{
+			name:     "default ttl",
+			cfg:      CallbackConfig{TTL: 2 * time.Minute},
+			request:  callbackStepCtx("select", "bpp.example.com", "msg-1", ""),
+			callback: callbackStepCtx("on_select", "bpp.example.com", "msg-1", ""),
+			after:    time.Minute,
+		},
+		{
+			name:     "allowed unsolicited",
+			cfg:      CallbackConfig{Unsolicited: []string{"on_select"}},
+			callback: callbackStepCtx("on_select", "bpp.example.com", "msg-1", ""),
+		},
+		{
+			name:     "default unsolicited",
+			callback: callbackStepCtx("on_update", "bpp.example.com", "msg-1", ""),
+		},
+		{
+			name:     "configured unsolicited replace the defaults",
+			cfg:      CallbackConfig{Unsolicited: []string{"on_select"}},
+			callback: callbackStepCtx("on_status", "bpp.example.com", "msg-1", ""),
+			wantErr:  "unsolicited on_status",
+		},
+		{
+			name:     "no unsolicited",
+			cfg:      CallbackConfig{Unsolicited: []string{}},
+			callback: callbackStepCtx("on_cancel", "bpp.example.com", "msg-1", ""),
+			wantErr:  "unsolicited on_cancel",
+		},
+		{
+			name:     "warn",
+			cfg:      CallbackConfig{Mode: CheckWarn},
+			callback: callbackStepCtx("on_confirm", "bpp.example.com", "msg-1", ""),
+		},
+		{
+			name:     "request is not checked",
+			callback: callbackStepCtx("confirm", "bpp.example.com", "msg-1", ""),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newMockCache()
+			now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+			expect, err := newExpectCallbackStep(cache, &tt.cfg)
+			if err != nil {
+				t.Fatalf("newExpectCallbackStep() error = %v", err)
+			}
+			expect.now = func() time.Time { return now }
+			validate, err := newValidateCallbackStep(cache, &tt.cfg)
+			if err != nil {
+				t.Fatalf("newValidateCallbackStep() error = %v", err)
+			}
+			validate.now = func() time.Time { return now.Add(tt.after) }
+
+			if tt.request != nil {
+				if err := expect.Run(tt.request); err != nil {
+					t.Fatalf("expectCallback Run() error = %v", err)
+				}
+			}
+			if tt.signer != "" {
+				withStepValue(tt.callback, signValidatedKey{}, tt.signer)
+			}
+			err = validate.Run(tt.callback)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateCallback Run() error = %v, want nil", err)
+				}
+				return
+			}
+			var badReq *model.BadReqErr
+			if !errors.As(err, &badReq) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateCallback Run() error = %v, want BadReqErr containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// failingCache is a cache whose calls all fail.
+type failingCache struct {
+	definition.Cache
+}
+
+func (failingCache) Get(ctx context.Context, key string) (string, error) {
+	return "", errors.New("connection refused")
+}
+
+func (failingCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return errors.New("connection refused")
+}
+
+// TestCallbackCacheFailure tests that requests are sent and callbacks accepted if the cache fails.
+func TestCallbackCacheFailure(t *testing.T) {
+	cfg := &CallbackConfig{}
+	expect, err := newExpectCallbackStep(failingCache{}, cfg)
+	if err != nil {
+		t.Fatalf("newExpectCallbackStep() error = %v", err)
+	}
+	validate, err := newValidateCallbackStep(failingCache{}, cfg)
+	if err != nil {
+		t.Fatalf("newValidateCallbackStep() error = %v", err)
+	}
+	if err := expect.Run(callbackStepCtx("select", "bpp.example.com", "msg-1", "PT30S")); err != nil {
+		t.Errorf("expectCallback Run() error = %v, want nil", err)
+	}
+	if err := validate.Run(callbackStepCtx("on_select", "bpp.example.com", "msg-1", "")); err != nil {
+		t.Errorf("validateCallback Run() error = %v, want nil", err)
+	}
+}
+
+// TestExpectCallbackStepTTL tests that expected callbacks are kept past their deadline
+// so that late callbacks can be told from unsolicited ones.
+func TestExpectCallbackStepTTL(t *testing.T) {
+	cache := newMockCache()
+	s, err := newExpectCallbackStep(cache, &CallbackConfig{})
+	if err != nil {
+		t.Fatalf("newExpectCallbackStep() error = %v", err)
+	}
+	if err := s.Run(callbackStepCtx("init", "bpp.example.com", "msg-1", "PT1M")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	key := callbackKey("bap.example.com", "bpp.example.com", "init", "msg-1")
+	if got, want := cache.ttls[key], time.Minute+callbackLateWindow; got != want {
+		t.Errorf("ttl = %v, want %v", got, want)
+	}
+
+	// Callbacks themselves are not recorded.
+	if err := s.Run(callbackStepCtx("on_init", "bpp.example.com", "msg-2", "PT1M")); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(cache.data) != 1 {
+		t.Errorf("cache entries = %d, want 1", len(cache.data))
+	}
+}
+
+// TestNewCallbackStepsFailure tests that the callback steps require the cache and a valid mode.
+func TestNewCallbackStepsFailure(t *testing.T) {
+	if _, err := newExpectCallbackStep(nil, &CallbackConfig{}); err == nil {
+		t.Error("newExpectCallbackStep(nil cache) error = nil, want error")
+	}
+	if _, err := newValidateCallbackStep(nil, &CallbackConfig{}); err == nil {
+		t.Error("newValidateCallbackStep(nil cache) error = nil, want error")
+	}
+	if _, err := newValidateCallbackStep(newMockCache(), &CallbackConfig{Mode: "strict"}); err == nil || !strings.Contains(err.Error(), "invalid mode") {
+		t.Errorf("newValidateCallbackStep(strict) error = %v, want invalid mode", err)
+	}
+}
+
+// TestParseISODuration tests parsing the ISO 8601 durations of context.ttl.
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "PT30S", want: 30 * time.Second},
+		{in: "PT1.5S", want: 1500 * time.Millisecond},
+		{in: "PT1H30M", want: 90 * time.Minute},
+		{in: "P1DT2H", want: 26 * time.Hour},
+		{in: "P1W", want: 7 * 24 * time.Hour},
+		{in: "P", wantErr: true},
+		{in: "PT", wantErr: true},
+		{in: "30S", wantErr: true},
+		{in: "PT30", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseISODuration(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseISODuration(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseISODuration(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"path"
+	"slices"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// conditionalStep runs a step only for the requests selected by its conditions.
+type conditionalStep struct {
+	name   string
+	step   definition.Step
+	when   *StepCondition
+	unless *StepCondition
+}
+
+// Run runs the wrapped step if the request matches when and does not match unless.
+func (s *conditionalStep) Run(ctx *model.StepContext) error {
+	bc := conditionContext(ctx)
+	if (s.when != nil && !s.when.matches(ctx, bc)) || (s.unless != nil && s.unless.matches(ctx, bc)) {
+		log.Debugf(ctx, "Skipping step %s for action %s", s.name, bc.Action)
+		return nil
+	}
+	return s.step.Run(ctx)
+}
+
+// conditionContext returns the Beckn context that step conditions select on. The
+// action is the last segment of the request path, the endpoint that the request is
+// routed on, so that a body claiming another action cannot skip a step.
+func conditionContext(ctx *model.StepContext) model.BecknContext {
+	var bc model.BecknContext
+	if parsed, err := ctx.BecknContext(); err == nil {
+		bc = *parsed
+	}
+	if ctx.Request != nil {
+		bc.Action = path.Base(ctx.Request.URL.Path)
+	}
+	return bc
+}
+
+// matches reports whether the request matches every field of the condition that is set.
+func (c *StepCondition) matches(ctx *model.StepContext, bc model.BecknContext) bool {
+	if len(c.Action) > 0 && !slices.Contains(c.Action, bc.Action) {
+		return false
+	}
+	if len(c.Domain) > 0 && !slices.Contains(c.Domain, bc.Domain) {
+		return false
+	}
+	if len(c.Version) > 0 && !slices.Contains(c.Version, bc.Version) {
+		return false
+	}
+	if len(c.Role) > 0 && !slices.Contains(c.Role, ctx.Role) {
+		return false
+	}
+	for name, values := range c.Header {
+		if ctx.Request == nil {
+			return false
+		}
+		got, ok := ctx.Request.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return false
+		}
+		if len(values) > 0 && !slices.ContainsFunc(got, func(v string) bool { return slices.Contains(values, v) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// stepNames returns the names of the configured steps.
+func stepNames(steps []StepConfig) []string {
+	names := make([]string, 0, len(steps))
+	for _, s := range steps {
+		names = append(names, s.Name)
+	}
+	return names
+}
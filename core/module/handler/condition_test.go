@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// countingStep counts how often it runs.
+type countingStep struct {
+	runs int
+}
+
+func (s *countingStep) Run(ctx *model.StepContext) error {
+	s.runs++
+	return nil
+}
+
+// TestStepConfigUnmarshalYAML tests that steps can be given by name or with conditions.
+func TestStepConfigUnmarshalYAML(t *testing.T) {
+	data := `
+steps:
+  - validateSign
+  - name: validateSchema
+    when:
+      action: [confirm, init]
+      domain: [ONDC:RET10]
+  - name: audit
+    unless:
+      header:
+        X-Health-Probe: []
+`
+	var cfg struct {
+		Steps []StepConfig `yaml:"steps"`
+	}
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("yaml.Unmarshal() error = %v", err)
+	}
+	want := []StepConfig{
+		{Name: "validateSign"},
+		{Name: "validateSchema", When: &StepCondition{Action: []string{"confirm", "init"}, Domain: []string{"ONDC:RET10"}}},
+		{Name: "audit", Unless: &StepCondition{Header: map[string][]string{"X-Health-Probe": {}}}},
+	}
+	if !reflect.DeepEqual(cfg.Steps, want) {
+		t.Errorf("Steps = %+v, want %+v", cfg.Steps, want)
+	}
+}
+
+// TestStepConditionMatches tests the matching of step conditions against requests.
+func TestStepConditionMatches(t *testing.T) {
+	body := `{"context":{"action":"confirm","domain":"ONDC:RET10","version":"1.2.0"}}`
+	tests := []struct {
+		name   string
+		cond   StepCondition
+		path   string
+		body   string
+		header http.Header
+		want   bool
+	}{
+		{name: "empty", want: true},
+		{name: "action", cond: StepCondition{Action: []string{"init", "confirm"}}, want: true},
+		{name: "other action", cond: StepCondition{Action: []string{"search"}}, want: false},
+		{name: "action from path", cond: StepCondition{Action: []string{"status"}}, path: "/bpp/receiver/status", body: `{}`, want: true},
+		{name: "body action differs from path", cond: StepCondition{Action: []string{"confirm"}}, path: "/bpp/receiver/health", want: false},
+		{name: "domain and version", cond: StepCondition{Domain: []string{"ONDC:RET10"}, Version: []string{"1.2.0"}}, want: true},
+		{name: "other version", cond: StepCondition{Domain: []string{"ONDC:RET10"}, Version: []string{"2.0.0"}}, want: false},
+		{name: "role", cond: StepCondition{Role: []model.Role{model.RoleBAP}}, want: true},
+		{name: "other role", cond: StepCondition{Role: []model.Role{model.RoleBPP}}, want: false},
+		{name: "header present", cond: StepCondition{Header: map[string][]string{"x-health-probe": nil}}, header: http.Header{"X-Health-Probe": {"1"}}, want: true},
+		{name: "header missing", cond: StepCondition{Header: map[string][]string{"X-Health-Probe": nil}}, want: false},
+		{name: "header value", cond: StepCondition{Header: map[string][]string{"X-Tenant": {"a", "b"}}}, header: http.Header{"X-Tenant": {"b"}}, want: true},
+		{name: "other header value", cond: StepCondition{Header: map[string][]string{"X-Tenant": {"a"}}}, header: http.Header{"X-Tenant": {"c"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.path == "" {
+				tt.path = "/bpp/receiver/confirm"
+			}
+			if tt.body == "" {
+				tt.body = body
+			}
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			ctx := &model.StepContext{Context: req.Context(), Request: req, Body: []byte(tt.body), Role: model.RoleBAP}
+			if got := tt.cond.matches(ctx, conditionContext(ctx)); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestConditionalStep tests that a conditional step runs only for the requests it selects.
+func TestConditionalStep(t *testing.T) {
+	tests := []struct {
+		name       string
+		when       *StepCondition
+		unless     *StepCondition
+		action     string
+		bodyAction string
+		wantRuns   int
+	}{
+		{name: "when matches", when: &StepCondition{Action: []string{"confirm"}}, action: "confirm", wantRuns: 1},
+		{name: "when does not match", when: &StepCondition{Action: []string{"confirm"}}, action: "search", wantRuns: 0},
+		{name: "unless matches", unless: &StepCondition{Action: []string{"search"}}, action: "search", wantRuns: 0},
+		{name: "unless does not match", unless: &StepCondition{Action: []string{"search"}}, action: "confirm", wantRuns: 1},
+		{name: "unless matches body action only", unless: &StepCondition{Action: []string{"health"}}, action: "confirm", bodyAction: "health", wantRuns: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := &countingStep{}
+			h := &stdHandler{steps: []definition.Step{&conditionalStep{name: "count", step: step, when: tt.when, unless: tt.unless}}}
+			if tt.bodyAction == "" {
+				tt.bodyAction = tt.action
+			}
+			body := `{"context":{"action":"` + tt.bodyAction + `"}}`
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bpp/receiver/"+tt.action, strings.NewReader(body)))
+			if step.runs != tt.wantRuns {
+				t.Errorf("runs = %d, want %d", step.runs, tt.wantRuns)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -64,15 +65,234 @@ type HttpClientConfig struct {
 	// ResponseHeaderTimeout, if non-zero, specifies the amount of time to wait
 	// for a server's response headers after fully writing the request.
 	ResponseHeaderTimeout time.Duration `yaml:"responseHeaderTimeout"`
+
+	// Retry controls how failed outbound requests are retried.
+	Retry RetryConfig `yaml:"retry"`
+
+	// CircuitBreaker controls the per-host circuit breaker for outbound requests.
+	CircuitBreaker CircuitBreakerConfig `yaml:"circuitBreaker"`
+}
+
+// RetryConfig defines the retry policy for outbound requests.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts per request, including the first.
+	// A value of 0 or 1 disables retries.
+	MaxAttempts int `yaml:"maxAttempts"`
+
+	// Backoff is the wait before the first retry; it doubles on every further retry.
+	Backoff time.Duration `yaml:"backoff"`
+
+	// StatusCodes lists the response status codes that are retried.
+	// Transport errors are always retried.
+	StatusCodes []int `yaml:"statusCodes"`
+}
+
+// CircuitBreakerConfig defines the per-host circuit breaker for outbound requests.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit
+	// for a host. A value of 0 disables the circuit breaker.
+	FailureThreshold int `yaml:"failureThreshold"`
+
+	// OpenTimeout is how long the circuit stays open before a probe request is let through.
+	OpenTimeout time.Duration `yaml:"openTimeout"`
+}
+
+// AsyncConfig defines the configuration for asynchronous delivery of routed requests.
+// When enabled, the handler sends the ACK as soon as all steps pass and delivers
+// the request to its route in the background.
+type AsyncConfig struct {
+	// Enabled switches the handler to asynchronous delivery.
+	Enabled bool `yaml:"enabled"`
+
+	// Workers is the number of concurrent delivery workers for the module.
+	Workers int `yaml:"workers"`
+
+	// QueueSize is the number of requests that can wait for a worker.
+	// Requests arriving while the queue is full are NACKed.
+	QueueSize int `yaml:"queueSize"`
+
+	// MaxAttempts is the maximum number of delivery attempts per request.
+	MaxAttempts int `yaml:"maxAttempts"`
+
+	// Backoff is the wait before the first retry; it doubles on every further retry.
+	Backoff time.Duration `yaml:"backoff"`
+}
+
+// AckPolicy decides when a request broadcast to several destinations is ACKed.
+type AckPolicy string
+
+const (
+	// AckPolicyAll ACKs only if every destination accepted the request.
+	AckPolicyAll AckPolicy = "all"
+	// AckPolicyAny ACKs once one destination accepted the request, without waiting for the others.
+	AckPolicyAny AckPolicy = "any"
+	// AckPolicyNone ACKs immediately and delivers to the destinations in the background.
+	AckPolicyNone AckPolicy = "none"
+)
+
+// BroadcastConfig defines how requests routed to several destinations are delivered.
+type BroadcastConfig struct {
+	// AckPolicy decides when the caller is ACKed. Defaults to AckPolicyAny.
+	AckPolicy AckPolicy `yaml:"ackPolicy"`
+}
+
+// ResponseSigningConfig defines the signing of synchronous ACK and NACK responses.
+type ResponseSigningConfig struct {
+	// Sign makes the handler sign the body of every response it sends with the
+	// keyset of the module and set the Authorization header. It requires the
+	// Signer and KeyManager plugins.
+	Sign bool `yaml:"sign"`
+
+	// Verify makes the handler reject responses from upstream participants whose
+	// body is not signed by them. It requires the SignValidator and KeyManager plugins.
+	Verify bool `yaml:"verify"`
+}
+
+// DuplicatePolicy decides how the idempotency step answers a duplicate message.
+type DuplicatePolicy string
+
+const (
+	// DuplicateRespond answers a duplicate with the response sent for the first message.
+	DuplicateRespond DuplicatePolicy = "respond"
+	// DuplicateDrop ACKs a duplicate without routing it.
+	DuplicateDrop DuplicatePolicy = "drop"
+)
+
+// IdempotencyConfig defines how the idempotency step detects and answers messages
+// that a subscriber sends again with the same action and message_id.
+type IdempotencyConfig struct {
+	// TTL is how long a message is remembered. Defaults to 10 minutes.
+	TTL time.Duration `yaml:"ttl"`
+
+	// Duplicates decides how duplicates are answered. Defaults to DuplicateRespond.
+	Duplicates DuplicatePolicy `yaml:"duplicates"`
+}
+
+// CheckMode decides what the validateSequence and validateCallback steps do with
+// the messages that fail their check.
+type CheckMode string
+
+const (
+	// CheckEnforce NACKs messages that fail the check.
+	CheckEnforce CheckMode = "enforce"
+	// CheckWarn logs messages that fail the check and processes them.
+	CheckWarn CheckMode = "warn"
+	// CheckOff disables the check.
+	CheckOff CheckMode = "off"
+)
+
+// validateCheckMode returns an error if mode is not a known CheckMode.
+func validateCheckMode(mode CheckMode) error {
+	switch mode {
+	case CheckEnforce, CheckWarn, CheckOff:
+		return nil
+	default:
+		return fmt.Errorf("invalid mode %q: must be enforce, warn or off", mode)
+	}
+}
+
+// SequenceConfig defines how the validateSequence step tracks transactions and
+// checks the order of their messages.
+type SequenceConfig struct {
+	// Mode applies to domains without their own mode. Defaults to CheckEnforce.
+	Mode CheckMode `yaml:"mode"`
+
+	// Domains sets the mode of individual domains or of glob patterns of domains.
+	Domains map[string]CheckMode `yaml:"domains"`
+
+	// TTL is how long a transaction is tracked after its last request. Defaults to 24 hours.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// CallbackConfig defines how the expectCallback step of a caller module records the
+// requests it sends and how the validateCallback step of a receiver module checks the
+// callbacks it receives against them.
+type CallbackConfig struct {
+	// Mode decides what validateCallback does with unsolicited and late callbacks.
+	// Defaults to CheckEnforce.
+	Mode CheckMode `yaml:"mode"`
+
+	// TTL is how long a callback is expected for requests without a valid context.ttl.
+	// Defaults to 30 seconds.
+	TTL time.Duration `yaml:"ttl"`
+
+	// Grace is how long after the TTL of its request a callback is still accepted.
+	Grace time.Duration `yaml:"grace"`
+
+	// Unsolicited lists the callback actions accepted without a request. Defaults to
+	// on_status, on_update, on_cancel and on_track; an empty list accepts none.
+	Unsolicited []string `yaml:"unsolicited"`
+}
+
+// StepConfig is a processing step of the handler. In YAML it is either the name of
+// the step or a mapping with the name and the conditions under which the step runs.
+type StepConfig struct {
+	Name string `yaml:"name"`
+
+	// When makes the step run only for requests that match the condition.
+	When *StepCondition `yaml:"when,omitempty"`
+
+	// Unless skips the step for requests that match the condition.
+	Unless *StepCondition `yaml:"unless,omitempty"`
+}
+
+// UnmarshalYAML accepts a step given by its name as well as a step with conditions.
+func (s *StepConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var name string
+	if err := unmarshal(&name); err == nil {
+		*s = StepConfig{Name: name}
+		return nil
+	}
+	type plain StepConfig
+	return unmarshal((*plain)(s))
+}
+
+// StepCondition selects requests by the fields of their Beckn context, their headers
+// and the role of the handler. A request matches if every field that is set matches,
+// and a field matches if the request has any of its values.
+type StepCondition struct {
+	Action  []string     `yaml:"action,omitempty"`
+	Domain  []string     `yaml:"domain,omitempty"`
+	Version []string     `yaml:"version,omitempty"`
+	Role    []model.Role `yaml:"role,omitempty"`
+
+	// Header maps header names to their accepted values. A header without values
+	// only has to be present.
+	Header map[string][]string `yaml:"header,omitempty"`
 }
 
 // Config holds the configuration for request processing handlers.
 type Config struct {
 	Plugins          PluginCfg `yaml:"plugins"`
-	Steps            []string
+	Steps            []StepConfig
 	Type             Type
 	RegistryURL      string `yaml:"registryUrl"`
 	Role             model.Role
-	SubscriberID     string           `yaml:"subscriberId"`
-	HttpClientConfig HttpClientConfig `yaml:"httpClientConfig"`
+	SubscriberID     string                `yaml:"subscriberId"`
+	HttpClientConfig HttpClientConfig      `yaml:"httpClientConfig"`
+	Async            AsyncConfig           `yaml:"async"`
+	Broadcast        BroadcastConfig       `yaml:"broadcast"`
+	ResponseSigning  ResponseSigningConfig `yaml:"responseSigning"`
+
+	// ResponseSteps run on the upstream response of requests routed to a url.
+	// A response they reject is replaced with a NACK.
+	ResponseSteps []string `yaml:"responseSteps"`
+
+	// PostSteps run after the request is routed and the caller has its response,
+	// with the outcome of routing in StepContext.RouteResult.
+	PostSteps []string `yaml:"postSteps"`
+
+	// ReplayProtection makes the validateSign step reject requests whose signature
+	// and message_id were already accepted. It requires the Cache plugin.
+	ReplayProtection bool `yaml:"replayProtection"`
+
+	// Idempotency configures the idempotency step. It requires the Cache plugin.
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+
+	// Sequence configures the validateSequence step. It requires the Cache plugin.
+	Sequence SequenceConfig `yaml:"sequence"`
+
+	// Callbacks configures the expectCallback and validateCallback steps. They require
+	// the Cache plugin.
+	Callbacks CallbackConfig `yaml:"callbacks"`
 }
@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// subscriberTypeBPP is the registry subscriber type of BPPs.
+const subscriberTypeBPP = "BPP"
+
+// subscriptionStatusSubscribed is the registry status of active subscriptions.
+const subscriptionStatusSubscribed = "SUBSCRIBED"
+
+// addGatewayRouteStep routes requests received by a Beckn Gateway. A search without
+// a bpp_uri is broadcast to every BPP subscribed to its domain and city, and an
+// on_search is relayed to the bap_uri of the search.
+type addGatewayRouteStep struct {
+	registry definition.RegistryLookup
+}
+
+// newAddGatewayRouteStep creates and returns the addGatewayRoute step after validation.
+func newAddGatewayRouteStep(registry definition.RegistryLookup) (definition.Step, error) {
+	if registry == nil {
+		return nil, fmt.Errorf("invalid config: Registry plugin not configured")
+	}
+	return &addGatewayRouteStep{registry: registry}, nil
+}
+
+// Run executes the gateway routing step.
+func (s *addGatewayRouteStep) Run(ctx *model.StepContext) error {
+	bc, err := ctx.BecknContext()
+	if err != nil {
+		return model.NewBadReqErr(fmt.Errorf("failed to parse request body: %w", err))
+	}
+
+	switch bc.Action {
+	case "search":
+		if bc.BPPURI != "" {
+			return s.routeTo(ctx, "bpp_uri", bc.BPPURI, bc.Action)
+		}
+		return s.broadcast(ctx, bc)
+	case "on_search":
+		return s.routeTo(ctx, "bap_uri", bc.BAPURI, bc.Action)
+	default:
+		return model.NewBadReqErr(fmt.Errorf("action %q is not handled by the gateway", bc.Action))
+	}
+}
+
+// routeTo routes the request to the action endpoint of a single participant.
+func (s *addGatewayRouteStep) routeTo(ctx *model.StepContext, field, uri, action string) error {
+	if uri == "" {
+		return model.NewBadReqErr(fmt.Errorf("context.%s is required for %s", field, action))
+	}
+	target, err := endpointURL(uri, action)
+	if err != nil {
+		return model.NewBadReqErr(fmt.Errorf("invalid context.%s: %w", field, err))
+	}
+	ctx.Route = &model.Route{TargetType: "url", URL: target}
+	return nil
+}
+
+// broadcast routes the request to every BPP the registry lists for its domain and city.
+func (s *addGatewayRouteStep) broadcast(ctx *model.StepContext, bc *model.BecknContext) error {
+	subs, err := s.registry.Lookup(ctx, &model.Subscription{
+		Subscriber: model.Subscriber{
+			Type:   subscriberTypeBPP,
+			Domain: bc.Domain,
+			City:   bc.CityCode(),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to look up BPPs: %w", err)
+	}
+	targets := subscriberURLs(ctx, subs, bc.Action)
+	if len(targets) == 0 {
+		return model.NewNotFoundErr(fmt.Errorf("no BPPs found for domain %s and city %s", bc.Domain, bc.CityCode()))
+	}
+	log.Infof(ctx, "Broadcasting %s to %d BPPs for domain %s and city %s", bc.Action, len(targets), bc.Domain, bc.CityCode())
+	ctx.Route = &model.Route{TargetType: "broadcast", URLs: targets}
+	return nil
+}
+
+// subscriberURLs returns the action endpoints of the subscribed participants,
+// skipping duplicates and entries without a valid URL.
+func subscriberURLs(ctx context.Context, subs []model.Subscription, action string) []*url.URL {
+	seen := map[string]bool{}
+	var targets []*url.URL
+	for _, sub := range subs {
+		if sub.Status != "" && sub.Status != subscriptionStatusSubscribed {
+			continue
+		}
+		if sub.URL == "" || seen[sub.URL] {
+			continue
+		}
+		seen[sub.URL] = true
+		target, err := endpointURL(sub.URL, action)
+		if err != nil {
+			log.Warnf(ctx, "Skipping subscriber %s with invalid URL %q: %v", sub.SubscriberID, sub.URL, err)
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return targets
+}
+
+// endpointURL appends the action to the path of a participant URI.
+func endpointURL(uri, action string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an absolute URL", uri)
+	}
+	u.Path = path.Join("/", u.Path, action)
+	return u, nil
+}
@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// mockRegistry returns fixed subscriptions and records the last lookup request.
+type mockRegistry struct {
+	subs []model.Subscription
+	err  error
+	req  *model.Subscription
+}
+
+func (m *mockRegistry) Lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	m.req = req
+	return m.subs, m.err
+}
+
+// gatewayStepCtx returns a step context for a gateway request with the given body.
+func gatewayStepCtx(body string) *model.StepContext {
+	return &model.StepContext{
+		Context: context.Background(),
+		Request: httptest.NewRequest(http.MethodPost, "/bg/receiver/search", strings.NewReader(body)),
+		Body:    []byte(body),
+		Role:    model.RoleGateway,
+	}
+}
+
+// TestAddGatewayRouteSearch tests that a search is broadcast to the subscribed BPPs of its domain and city.
+func TestAddGatewayRouteSearch(t *testing.T) {
+	registry := &mockRegistry{subs: []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "https://bpp1.example.com/beckn"}, Status: "SUBSCRIBED"},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp2", URL: "https://bpp2.example.com"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp1-dup", URL: "https://bpp1.example.com/beckn"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp3", URL: "https://bpp3.example.com"}, Status: "UNSUBSCRIBED"},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp4", URL: "not-a-url"}},
+	}}
+	step, err := newAddGatewayRouteStep(registry)
+	if err != nil {
+		t.Fatalf("newAddGatewayRouteStep() error = %v", err)
+	}
+	ctx := gatewayStepCtx(`{"context":{"action":"search","domain":"ONDC:RET10","location":{"city":{"code":"std:080"}}}}`)
+	if err := step.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if registry.req.Type != "BPP" || registry.req.Domain != "ONDC:RET10" || registry.req.City != "std:080" {
+		t.Errorf("Lookup() request = %+v, want BPPs of ONDC:RET10 in std:080", registry.req.Subscriber)
+	}
+	if ctx.Route.TargetType != "broadcast" {
+		t.Fatalf("Route.TargetType = %s, want broadcast", ctx.Route.TargetType)
+	}
+	var got []string
+	for _, u := range ctx.Route.URLs {
+		got = append(got, u.String())
+	}
+	want := []string{"https://bpp1.example.com/beckn/search", "https://bpp2.example.com/search"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Route.URLs = %v, want %v", got, want)
+	}
+}
+
+// TestAddGatewayRouteSuccess tests the routes of gateway requests that go to a single participant.
+func TestAddGatewayRouteSuccess(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "search with bpp_uri",
+			body: `{"context":{"action":"search","bpp_uri":"https://bpp.example.com/beckn"}}`,
+			want: "https://bpp.example.com/beckn/search",
+		},
+		{
+			name: "on_search relayed to bap_uri",
+			body: `{"context":{"action":"on_search","bap_uri":"https://bap.example.com"}}`,
+			want: "https://bap.example.com/on_search",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, _ := newAddGatewayRouteStep(&mockRegistry{})
+			ctx := gatewayStepCtx(tt.body)
+			if err := step.Run(ctx); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if ctx.Route.TargetType != "url" || ctx.Route.URL.String() != tt.want {
+				t.Errorf("Route = %s %v, want url %s", ctx.Route.TargetType, ctx.Route.URL, tt.want)
+			}
+		})
+	}
+}
+
+// TestAddGatewayRouteFailure tests that unroutable gateway requests are rejected.
+func TestAddGatewayRouteFailure(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry *mockRegistry
+		body     string
+		wantErr  any
+	}{
+		{
+			name:     "on_search without bap_uri",
+			registry: &mockRegistry{},
+			body:     `{"context":{"action":"on_search"}}`,
+			wantErr:  &model.BadReqErr{},
+		},
+		{
+			name:     "unsupported action",
+			registry: &mockRegistry{},
+			body:     `{"context":{"action":"select"}}`,
+			wantErr:  &model.BadReqErr{},
+		},
+		{
+			name:     "no BPPs found",
+			registry: &mockRegistry{},
+			body:     `{"context":{"action":"search","domain":"ONDC:RET10","city":"std:080"}}`,
+			wantErr:  &model.NotFoundErr{},
+		},
+		{
+			name:     "registry failure",
+			registry: &mockRegistry{err: errors.New("registry down")},
+			body:     `{"context":{"action":"search","domain":"ONDC:RET10"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, _ := newAddGatewayRouteStep(tt.registry)
+			err := step.Run(gatewayStepCtx(tt.body))
+			if err == nil {
+				t.Fatal("Run() error = nil, want error")
+			}
+			switch want := tt.wantErr.(type) {
+			case *model.BadReqErr:
+				if !errors.As(err, &want) {
+					t.Errorf("Run() error = %v, want BadReqErr", err)
+				}
+			case *model.NotFoundErr:
+				if !errors.As(err, &want) {
+					t.Errorf("Run() error = %v, want NotFoundErr", err)
+				}
+			}
+		})
+	}
+}
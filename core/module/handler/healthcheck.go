@@ -1,11 +1,27 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+)
+
+// Status values reported by the readiness endpoint.
+const (
+	statusOK       = "ok"
+	statusError    = "error"
+	statusReady    = "ready"
+	statusNotReady = "not_ready"
 )
 
+// readyCheckTimeout bounds the time spent checking the dependencies of a module.
+const readyCheckTimeout = 5 * time.Second
+
 // HealthCheckResponse defines the structure for our health check JSON response.
 type healthCheckResponse struct {
 	Status  string `json:"status"`
@@ -32,4 +48,90 @@ func HealthHandler(w http.ResponseWriter, r *http.Request) {
 		fmt.Printf("Error encoding health check response: %v\n", err)
 		return
 	}
-}
\ No newline at end of file
+}
+
+// Closer is implemented by handlers that hold resources, such as background workers,
+// that must be released when the handler is replaced or the server shuts down.
+type Closer interface {
+	Close()
+}
+
+// DependencyChecker is implemented by handlers that can check the plugins they depend on.
+type DependencyChecker interface {
+	// CheckDependencies returns the result of each dependency check, keyed by dependency name.
+	CheckDependencies(ctx context.Context) map[string]error
+}
+
+// dependencyStatus is the health of a single dependency of a module.
+type dependencyStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// moduleStatus is the readiness of a module and its dependencies.
+type moduleStatus struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]dependencyStatus `json:"dependencies,omitempty"`
+}
+
+// readyResponse defines the JSON response of the /ready endpoint.
+type readyResponse struct {
+	Status  string                  `json:"status"`
+	Modules map[string]moduleStatus `json:"modules"`
+}
+
+// ReadyHandler returns a handler for the /ready endpoint. It checks the dependencies of
+// every module and responds with 503 Service Unavailable if any of them fails.
+func ReadyHandler(modules map[string]DependencyChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), readyCheckTimeout)
+		defer cancel()
+
+		resp := readyResponse{Status: statusReady, Modules: make(map[string]moduleStatus, len(modules))}
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		for name, checker := range modules {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ms := checkModule(ctx, name, checker)
+				mu.Lock()
+				resp.Modules[name] = ms
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		code := http.StatusOK
+		for _, ms := range resp.Modules {
+			if ms.Status != statusReady {
+				resp.Status = statusNotReady
+				code = http.StatusServiceUnavailable
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			log.Errorf(r.Context(), err, "Error encoding readiness response")
+		}
+	})
+}
+
+// checkModule runs the dependency checks of a single module.
+func checkModule(ctx context.Context, name string, checker DependencyChecker) moduleStatus {
+	ms := moduleStatus{Status: statusReady, Dependencies: map[string]dependencyStatus{}}
+	for dep, err := range checker.CheckDependencies(ctx) {
+		if err != nil {
+			log.Errorf(ctx, err, "Readiness check failed for %s in module %s", dep, name)
+			ms.Status = statusNotReady
+			ms.Dependencies[dep] = dependencyStatus{Status: statusError, Error: err.Error()}
+			continue
+		}
+		ms.Dependencies[dep] = dependencyStatus{Status: statusOK}
+	}
+	return ms
+}
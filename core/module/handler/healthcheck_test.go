@@ -1,7 +1,9 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -109,4 +111,118 @@ func TestHealthHandlerErrors(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+// mockDependencyChecker returns fixed dependency check results.
+type mockDependencyChecker map[string]error
+
+// CheckDependencies returns the configured results.
+func (m mockDependencyChecker) CheckDependencies(ctx context.Context) map[string]error {
+	return m
+}
+
+// mockHealthChecker is a plugin that returns a fixed health check result.
+type mockHealthChecker struct {
+	err error
+}
+
+// HealthCheck returns the configured error.
+func (m *mockHealthChecker) HealthCheck(ctx context.Context) error {
+	return m.err
+}
+
+// TestReadyHandler tests that the readiness endpoint reports per-module and per-dependency status.
+func TestReadyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		modules    map[string]DependencyChecker
+		wantCode   int
+		wantStatus string
+		wantDeps   map[string]map[string]string
+	}{
+		{
+			name: "all dependencies healthy",
+			modules: map[string]DependencyChecker{
+				"bapTxnReceiver": mockDependencyChecker{"cache": nil, "keyManager": nil},
+			},
+			wantCode:   http.StatusOK,
+			wantStatus: statusReady,
+			wantDeps: map[string]map[string]string{
+				"bapTxnReceiver": {"cache": statusOK, "keyManager": statusOK},
+			},
+		},
+		{
+			name: "one dependency failing",
+			modules: map[string]DependencyChecker{
+				"bapTxnReceiver": mockDependencyChecker{"cache": errors.New("connection refused")},
+				"bapTxnCaller":   mockDependencyChecker{"publisher": nil},
+			},
+			wantCode:   http.StatusServiceUnavailable,
+			wantStatus: statusNotReady,
+			wantDeps: map[string]map[string]string{
+				"bapTxnReceiver": {"cache": statusError},
+				"bapTxnCaller":   {"publisher": statusOK},
+			},
+		},
+		{
+			name:       "no modules",
+			modules:    map[string]DependencyChecker{},
+			wantCode:   http.StatusOK,
+			wantStatus: statusReady,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			ReadyHandler(tt.modules).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+			if rr.Code != tt.wantCode {
+				t.Errorf("ReadyHandler returned status %d, want %d", rr.Code, tt.wantCode)
+			}
+			var resp readyResponse
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Fatalf("Failed to decode response body: %v", err)
+			}
+			if resp.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", resp.Status, tt.wantStatus)
+			}
+			for module, deps := range tt.wantDeps {
+				for dep, want := range deps {
+					if got := resp.Modules[module].Dependencies[dep].Status; got != want {
+						t.Errorf("%s.%s status = %q, want %q", module, dep, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+// TestReadyHandlerMethodNotAllowed tests that only GET is accepted on the readiness endpoint.
+func TestReadyHandlerMethodNotAllowed(t *testing.T) {
+	rr := httptest.NewRecorder()
+	ReadyHandler(nil).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/ready", nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("ReadyHandler returned status %d, want %d", rr.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// TestCheckDependencies tests that only plugins implementing HealthChecker are checked.
+func TestCheckDependencies(t *testing.T) {
+	h := &stdHandler{}
+	h.addChecker("cache", &mockHealthChecker{})
+	h.addChecker("publisher", &mockHealthChecker{err: errors.New("channel closed")})
+	h.addChecker("router", struct{}{})
+	h.addChecker("signer", nil)
+
+	got := h.CheckDependencies(context.Background())
+	if len(got) != 2 {
+		t.Fatalf("CheckDependencies() returned %d results, want 2: %v", len(got), got)
+	}
+	if got["cache"] != nil {
+		t.Errorf("cache error = %v, want nil", got["cache"])
+	}
+	if got["publisher"] == nil {
+		t.Error("publisher error = nil, want error")
+	}
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/response"
+)
+
+const (
+	// idempotencyKeyPrefix namespaces the idempotency entries in the cache.
+	idempotencyKeyPrefix = "idempotency:"
+
+	// defaultIdempotencyTTL is how long messages are remembered if no TTL is configured.
+	defaultIdempotencyTTL = 10 * time.Minute
+
+	// maxRecordedBody is the largest response body stored for duplicates. Messages
+	// with larger responses are forgotten, so their duplicates are processed again.
+	maxRecordedBody = 64 << 10
+)
+
+// idempotencyKey is the context key under which the idempotency step passes the
+// cache key of a new message to the handler, which stores the response under it.
+type idempotencyKey struct{}
+
+// idempotencyRecord is the cache entry of a message. Status is 0 while the first
+// message is still being processed.
+type idempotencyRecord struct {
+	Status int    `json:"status"`
+	Body   string `json:"body,omitempty"`
+}
+
+// idempotencyStep detects messages that a subscriber sends again with the same
+// action and message_id, e.g. when it retries after a timeout.
+type idempotencyStep struct {
+	cache  definition.Cache
+	ttl    time.Duration
+	policy DuplicatePolicy
+}
+
+// newIdempotencyStep creates and returns the idempotency step.
+func newIdempotencyStep(cache definition.Cache, cfg *IdempotencyConfig) (*idempotencyStep, error) {
+	if cache == nil {
+		return nil, fmt.Errorf("invalid config: idempotency step requires the Cache plugin")
+	}
+	s := &idempotencyStep{cache: cache, ttl: cfg.TTL, policy: cfg.Duplicates}
+	if s.ttl <= 0 {
+		s.ttl = defaultIdempotencyTTL
+	}
+	switch s.policy {
+	case "":
+		s.policy = DuplicateRespond
+	case DuplicateRespond, DuplicateDrop:
+	default:
+		return nil, fmt.Errorf("invalid idempotency duplicates %q: must be respond or drop", s.policy)
+	}
+	return s, nil
+}
+
+// Run records a new message in the cache and stops the processing of a duplicate.
+// The lookup and the write are not atomic, so two copies of a message arriving at
+// the same moment may both be processed. If the cache fails, the message is processed.
+func (s *idempotencyStep) Run(ctx *model.StepContext) error {
+	action := conditionContext(ctx).Action
+	_, msgID := messageIDs(ctx, ctx.Body)
+	if msgID == "" {
+		log.Debugf(ctx, "No message_id for %s, skipping idempotency check", action)
+		return nil
+	}
+	sender := sender(ctx, action)
+	if sender == "" {
+		log.Debugf(ctx, "No sender for %s, skipping idempotency check", action)
+		return nil
+	}
+	key := idempotencyKeyPrefix + sender + "|" + action + "|" + msgID
+
+	if value, err := s.cache.Get(ctx, key); err == nil {
+		var rec idempotencyRecord
+		if err := json.Unmarshal([]byte(value), &rec); err != nil {
+			log.Errorf(ctx, err, "Invalid idempotency record for message_id %s, processing message", msgID)
+		} else {
+			metrics.ObserveDuplicate(ctx, action)
+			log.Warnf(ctx, "Duplicate %s from %s with message_id %s", action, sender, msgID)
+			return &duplicateMessage{record: rec, policy: s.policy}
+		}
+	}
+	pending, _ := json.Marshal(idempotencyRecord{})
+	if err := s.cache.Set(ctx, key, string(pending), s.ttl); err != nil {
+		log.Errorf(ctx, err, "Failed to record message_id %s for idempotency", msgID)
+		return nil
+	}
+	withStepValue(ctx, idempotencyKey{}, key)
+	return nil
+}
+
+// sender returns the subscriber that sent the request with action, the bpp_id of a
+// callback and the bap_id of other actions, as signed in the body.
+func sender(ctx *model.StepContext, action string) string {
+	bc, err := ctx.BecknContext()
+	if err != nil {
+		return ""
+	}
+	if strings.HasPrefix(action, "on_") {
+		return bc.BPPID
+	}
+	return bc.BAPID
+}
+
+// record stores the ACK sent for a new message, so that its duplicates can be
+// answered with it. Only messages whose signature the validateSign step validated are
+// recorded, so that a forged message cannot claim the message_id of its sender. Other
+// messages are forgotten, so that a retry after a NACK is processed again.
+func (s *idempotencyStep) record(ctx *model.StepContext, w *recordingResponseWriter) {
+	key, ok := ctx.Value(idempotencyKey{}).(string)
+	if !ok {
+		return
+	}
+	if !signValidated(ctx) || !w.acked() {
+		if err := s.cache.Delete(ctx, key); err != nil {
+			log.Errorf(ctx, err, "Failed to forget message for idempotency")
+		}
+		return
+	}
+	value, _ := json.Marshal(idempotencyRecord{Status: w.status, Body: w.body.String()})
+	if err := s.cache.Set(ctx, key, string(value), s.ttl); err != nil {
+		log.Errorf(ctx, err, "Failed to record response for idempotency")
+	}
+}
+
+// duplicateMessage is returned by the idempotency step to stop the processing of a
+// duplicate. The handler answers it with respond instead of a NACK.
+type duplicateMessage struct {
+	record idempotencyRecord
+	policy DuplicatePolicy
+}
+
+func (d *duplicateMessage) Error() string {
+	return "duplicate message"
+}
+
+// respond answers the duplicate. Duplicates of a message that is still being
+// processed, and all duplicates under DuplicateDrop, are ACKed.
+func (d *duplicateMessage) respond(w http.ResponseWriter) {
+	if d.policy == DuplicateDrop || d.record.Status == 0 {
+		response.SendAck(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(d.record.Status)
+	w.Write([]byte(d.record.Body))
+}
+
+// recordingResponseWriter passes the response of the handler through and keeps a
+// copy of its status and body for the idempotency step.
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	overflow bool
+}
+
+// WriteHeader records the status code of the response.
+func (w *recordingResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns the underlying ResponseWriter.
+func (w *recordingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// acked reports whether the recorded response is a complete ACK.
+func (w *recordingResponseWriter) acked() bool {
+	if w.status != http.StatusOK || w.overflow {
+		return false
+	}
+	var resp model.Response
+	return json.Unmarshal(w.body.Bytes(), &resp) == nil && resp.Message.Ack.Status == model.StatusACK
+}
+
+// Write records the response body, up to maxRecordedBody.
+func (w *recordingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.overflow {
+		if w.body.Len()+len(b) > maxRecordedBody {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(b)
+		}
+	}
+	return w.ResponseWriter.Write(b)
+}
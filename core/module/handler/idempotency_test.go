@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+const idempotencyTestBody = `{"context":{"action":"confirm","bap_id":"bap.example.com","transaction_id":"txn-1","message_id":"msg-1"}}`
+
+// signValidatedStep marks requests as validated by the validateSign step.
+type signValidatedStep struct{}
+
+func (signValidatedStep) Run(ctx *model.StepContext) error {
+	withStepValue(ctx, signValidatedKey{}, "bap.example.com")
+	return nil
+}
+
+// idempotencyHandler returns a handler with the idempotency step that proxies to
+// an upstream server answering with status and body, and the number of requests
+// the upstream server received. If signed is set, requests pass signature validation.
+func idempotencyHandler(t *testing.T, policy DuplicatePolicy, cache definition.Cache, status int, body string, signed bool) (*stdHandler, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+
+	step, err := newIdempotencyStep(cache, &IdempotencyConfig{Duplicates: policy})
+	if err != nil {
+		t.Fatalf("newIdempotencyStep() error = %v", err)
+	}
+	h := &stdHandler{
+		steps:       []definition.Step{&instrumentedStep{name: "idempotency", step: step}},
+		httpClient:  &http.Client{},
+		idempotency: step,
+	}
+	if signed {
+		h.steps = append(h.steps, signValidatedStep{})
+	}
+	h.steps = append(h.steps, &routeStep{route: &model.Route{TargetType: "url", URL: target}})
+	return h, &hits
+}
+
+// serveIdempotent sends body to the confirm endpoint.
+func serveIdempotent(h http.Handler, body string) *httptest.ResponseRecorder {
+	return serveIdempotentAction(h, "confirm", body)
+}
+
+// serveIdempotentAction sends body to the endpoint of action.
+func serveIdempotentAction(h http.Handler, action, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/bpp/receiver/"+action, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// TestIdempotencyStep tests that duplicates are answered with the recorded ACK
+// without routing them again, and that other responses are not recorded.
+func TestIdempotencyStep(t *testing.T) {
+	ack := `{"message":{"ack":{"status":"ACK"}}}`
+	nack := `{"message":{"ack":{"status":"NACK"},"error":{"code":"30000","message":"invalid item"}}}`
+	tests := []struct {
+		name       string
+		policy     DuplicatePolicy
+		status     int
+		body       string
+		unsigned   bool
+		wantStatus int
+		wantBody   string
+		wantHits   int32
+	}{
+		{name: "respond with stored ack", policy: DuplicateRespond, status: http.StatusOK, body: ack, wantStatus: http.StatusOK, wantBody: ack, wantHits: 1},
+		{name: "drop", policy: DuplicateDrop, status: http.StatusOK, body: ack, wantStatus: http.StatusOK, wantHits: 1},
+		{name: "nack is not stored", policy: DuplicateRespond, status: http.StatusBadRequest, body: nack, wantStatus: http.StatusBadRequest, wantBody: nack, wantHits: 2},
+		{name: "nack with status 200 is not stored", policy: DuplicateRespond, status: http.StatusOK, body: nack, wantStatus: http.StatusOK, wantBody: nack, wantHits: 2},
+		{name: "server error is not stored", policy: DuplicateRespond, status: http.StatusServiceUnavailable, body: nack, wantStatus: http.StatusServiceUnavailable, wantBody: nack, wantHits: 2},
+		{name: "unsigned message is not stored", policy: DuplicateRespond, status: http.StatusOK, body: ack, unsigned: true, wantStatus: http.StatusOK, wantBody: ack, wantHits: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, hits := idempotencyHandler(t, tt.policy, newMockCache(), tt.status, tt.body, !tt.unsigned)
+			first := serveIdempotent(h, idempotencyTestBody)
+			if first.Code != tt.status {
+				t.Fatalf("first status = %d, want %d", first.Code, tt.status)
+			}
+
+			dup := serveIdempotent(h, idempotencyTestBody)
+			if dup.Code != tt.wantStatus {
+				t.Errorf("duplicate status = %d, want %d", dup.Code, tt.wantStatus)
+			}
+			if tt.wantBody != "" && dup.Body.String() != tt.wantBody {
+				t.Errorf("duplicate body = %s, want %s", dup.Body.String(), tt.wantBody)
+			}
+			if tt.wantBody == "" && ackStatus(t, dup) != model.StatusACK {
+				t.Errorf("duplicate ack status = %s, want ACK", ackStatus(t, dup))
+			}
+			if got := hits.Load(); got != tt.wantHits {
+				t.Errorf("upstream requests = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+}
+
+// TestIdempotencyStepKeys tests that messages are told apart by sender, action and message_id.
+func TestIdempotencyStepKeys(t *testing.T) {
+	cache := newMockCache()
+	h, hits := idempotencyHandler(t, DuplicateRespond, cache, http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`, true)
+
+	serveIdempotent(h, idempotencyTestBody)
+	serveIdempotent(h, strings.Replace(idempotencyTestBody, "bap.example.com", "other.example.com", 1))
+	serveIdempotent(h, strings.Replace(idempotencyTestBody, "msg-1", "msg-2", 1))
+	serveIdempotentAction(h, "init", strings.Replace(idempotencyTestBody, `"confirm"`, `"init"`, 1))
+	// The sender of a callback is its bpp_id.
+	serveIdempotentAction(h, "on_confirm", `{"context":{"action":"on_confirm","bap_id":"bap.example.com","bpp_id":"bpp.example.com","message_id":"msg-1"}}`)
+	// Messages without a message_id or a sender are not checked.
+	serveIdempotent(h, `{"context":{"action":"confirm","bap_id":"bap.example.com"}}`)
+	serveIdempotent(h, `{"context":{"action":"confirm","bap_id":"bap.example.com"}}`)
+	serveIdempotent(h, `{"context":{"action":"confirm","message_id":"msg-1"}}`)
+	serveIdempotent(h, `{"context":{"action":"confirm","message_id":"msg-1"}}`)
+	if got := hits.Load(); got != 9 {
+		t.Errorf("upstream requests = %d, want 9", got)
+	}
+	if _, ok := cache.data[idempotencyKeyPrefix+"bpp.example.com|on_confirm|msg-1"]; !ok {
+		t.Error("callback was not recorded under its bpp_id")
+	}
+
+	key := idempotencyKeyPrefix + "bap.example.com|confirm|msg-1"
+	var rec idempotencyRecord
+	if err := json.Unmarshal([]byte(cache.data[key]), &rec); err != nil {
+		t.Fatalf("record of %s = %q: %v", key, cache.data[key], err)
+	}
+	if rec.Status != http.StatusOK {
+		t.Errorf("recorded status = %d, want %d", rec.Status, http.StatusOK)
+	}
+	if cache.ttls[key] != defaultIdempotencyTTL {
+		t.Errorf("ttl = %v, want %v", cache.ttls[key], defaultIdempotencyTTL)
+	}
+}
+
+// TestIdempotencyStepInFlight tests that duplicates of a message still being processed are ACKed.
+func TestIdempotencyStepInFlight(t *testing.T) {
+	cache := newMockCache()
+	cache.data[idempotencyKeyPrefix+"bap.example.com|confirm|msg-1"] = `{"status":0}`
+	h, hits := idempotencyHandler(t, DuplicateRespond, cache, http.StatusOK, `{"message":{"ack":{"status":"ACK"}}}`, true)
+
+	rec := serveIdempotent(h, idempotencyTestBody)
+	if rec.Code != http.StatusOK || ackStatus(t, rec) != model.StatusACK {
+		t.Errorf("response = %d %s, want ACK", rec.Code, rec.Body.String())
+	}
+	if got := hits.Load(); got != 0 {
+		t.Errorf("upstream requests = %d, want 0", got)
+	}
+}
+
+// TestNewIdempotencyStep tests the defaults and validation of the idempotency config.
+func TestNewIdempotencyStep(t *testing.T) {
+	s, err := newIdempotencyStep(newMockCache(), &IdempotencyConfig{TTL: time.Minute})
+	if err != nil {
+		t.Fatalf("newIdempotencyStep() error = %v", err)
+	}
+	if s.ttl != time.Minute || s.policy != DuplicateRespond {
+		t.Errorf("step = %+v, want ttl 1m and policy respond", s)
+	}
+
+	if _, err := newIdempotencyStep(nil, &IdempotencyConfig{}); err == nil || !strings.Contains(err.Error(), "requires the Cache plugin") {
+		t.Errorf("newIdempotencyStep(nil cache) error = %v, want Cache plugin error", err)
+	}
+	if _, err := newIdempotencyStep(newMockCache(), &IdempotencyConfig{Duplicates: "ignore"}); err == nil || !strings.Contains(err.Error(), "invalid idempotency duplicates") {
+		t.Errorf("newIdempotencyStep(ignore) error = %v, want invalid duplicates error", err)
+	}
+}
+
+// TestInitIdempotencyFailure tests that the idempotency step requires the validateSign step.
+func TestInitIdempotencyFailure(t *testing.T) {
+	h := &stdHandler{cache: newMockCache()}
+	cfg := &Config{Steps: []StepConfig{{Name: "idempotency"}}}
+	if err := h.initSteps(context.Background(), nil, cfg); err == nil || !strings.Contains(err.Error(), "requires the validateSign step") {
+		t.Errorf("initSteps() error = %v, want validateSign error", err)
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// signingResponseWriter buffers the response of the handler so that its body can
+// be signed before it is sent.
+type signingResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	body    bytes.Buffer
+	relayed bool // relayed is set if the response was copied from upstream.
+}
+
+// WriteHeader records the status code of the response.
+func (w *signingResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+// Write buffers the response body.
+func (w *signingResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// Flush does nothing, as the response is sent once it is complete. It stops a flush,
+// such as the proxy's for a streamed upstream response, from reaching the underlying
+// ResponseWriter through Unwrap and sending an implicit 200 status.
+func (w *signingResponseWriter) Flush() {}
+
+// FlushError does nothing, like Flush, for http.ResponseController.
+func (w *signingResponseWriter) FlushError() error {
+	return nil
+}
+
+// Unwrap returns the underlying ResponseWriter.
+func (w *signingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusResponseWriter records the status code of the response for the post steps.
+type statusResponseWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader records the status code of the response.
+func (w *statusResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the response body, recording an implicit 200 status.
+func (w *statusResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter.
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// markRelayed marks the response written to w as copied from upstream, so that it
+// is sent with the headers and signature of the upstream participant.
+func markRelayed(w http.ResponseWriter) {
+	for {
+		switch rw := w.(type) {
+		case *signingResponseWriter:
+			rw.relayed = true
+			return
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = rw.Unwrap()
+		default:
+			return
+		}
+	}
+}
+
+// signResponse signs the buffered response body with the keyset of subID, sets the
+// Authorization header and sends the response. Only the ACKs and NACKs of the
+// handler are signed; a response relayed from upstream is sent as it is. If signing
+// fails the response is sent unsigned, so that the caller still learns the outcome
+// of its request.
+func signResponse(ctx context.Context, signer *signStep, subID string, w *signingResponseWriter) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.body.Len() > 0 && !w.relayed {
+		authHeader, err := signer.sign(ctx, subID, w.body.Bytes())
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to sign response, sending it unsigned")
+		} else {
+			w.Header().Set(model.AuthHeaderSubscriber, authHeader)
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		log.Errorf(ctx, err, "Failed to write signed response")
+	}
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// failingStep fails with the given error.
+type failingStep struct {
+	err error
+}
+
+func (s *failingStep) Run(ctx *model.StepContext) error {
+	return s.err
+}
+
+// TestSignResponse tests that ACK and NACK bodies are signed with the module keyset.
+func TestSignResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		steps      []definition.Step
+		wantStatus int
+		wantAck    model.Status
+	}{
+		{name: "ack", wantStatus: http.StatusOK, wantAck: model.StatusACK},
+		{
+			name:       "nack",
+			steps:      []definition.Step{&failingStep{err: model.NewBadReqErr(errors.New("bad request"))}},
+			wantStatus: http.StatusBadRequest,
+			wantAck:    model.StatusNACK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := newSignStep(&mockSigner{}, &signKeyManager{})
+			if err != nil {
+				t.Fatalf("newSignStep() error = %v", err)
+			}
+			h := &stdHandler{steps: tt.steps, SubscriberID: "bpp.example.com", respSigner: signer}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bpp/receiver/search", strings.NewReader(`{}`)))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), fmt.Sprintf(`"status":"%s"`, tt.wantAck)) {
+				t.Errorf("body = %s, want %s", rec.Body.String(), tt.wantAck)
+			}
+			auth := rec.Header().Get(model.AuthHeaderSubscriber)
+			if !strings.Contains(auth, `keyId="bpp.example.com|key-1|ed25519"`) || !strings.Contains(auth, `signature="signature"`) {
+				t.Errorf("%s = %q, want a signature by bpp.example.com", model.AuthHeaderSubscriber, auth)
+			}
+		})
+	}
+}
+
+// signatureValidator accepts only the signature "valid".
+type signatureValidator struct{}
+
+func (v *signatureValidator) Validate(ctx context.Context, body []byte, header string, publicKeyBase64 string) error {
+	if headerParam(header, "signature") != "valid" {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// TestResponseVerification tests that upstream responses of url routes without a
+// valid signature are replaced with a 502 NACK.
+func TestResponseVerification(t *testing.T) {
+	tests := []struct {
+		name       string
+		signature  string
+		wantStatus int
+	}{
+		{name: "valid", signature: "valid", wantStatus: http.StatusOK},
+		{name: "invalid", signature: "forged", wantStatus: http.StatusBadGateway},
+		{name: "unsigned", wantStatus: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.signature != "" {
+					w.Header().Set(model.AuthHeaderSubscriber, fmt.Sprintf(
+						`Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519",signature="%s"`, tt.signature))
+				}
+				fmt.Fprint(w, `{"message":{"ack":{"status":"ACK"}}}`)
+			}))
+			defer srv.Close()
+			target, _ := url.Parse(srv.URL)
+
+			h := &stdHandler{httpClient: &http.Client{}, signValidator: &signatureValidator{}, km: &mockKeyManager{}}
+			if err := h.initResponseSigning(&ResponseSigningConfig{Verify: true}); err != nil {
+				t.Fatalf("initResponseSigning() error = %v", err)
+			}
+			h.steps = []definition.Step{&routeStep{route: &model.Route{TargetType: "url", URL: target}}}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bap/caller/search", strings.NewReader(asyncTestBody)))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestSignResponseRelayed tests that a response relayed from upstream keeps its
+// body and signature instead of being signed by the module.
+func TestSignResponseRelayed(t *testing.T) {
+	const upstreamAuth = `Signature keyId="bpp.example.com|key-9|ed25519",signature="upstream"`
+	const upstreamBody = `{"message":{"ack":{"status":"ACK"}}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(model.AuthHeaderSubscriber, upstreamAuth)
+		fmt.Fprint(w, upstreamBody)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	signer, err := newSignStep(&mockSigner{}, &signKeyManager{})
+	if err != nil {
+		t.Fatalf("newSignStep() error = %v", err)
+	}
+	h := &stdHandler{
+		steps:        []definition.Step{&routeStep{route: &model.Route{TargetType: "url", URL: target}}},
+		SubscriberID: "bap.example.com",
+		httpClient:   &http.Client{},
+		respSigner:   signer,
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bap/caller/search", strings.NewReader(asyncTestBody)))
+
+	if got := rec.Header().Get(model.AuthHeaderSubscriber); got != upstreamAuth {
+		t.Errorf("%s = %q, want the upstream signature %q", model.AuthHeaderSubscriber, got, upstreamAuth)
+	}
+	if rec.Body.String() != upstreamBody {
+		t.Errorf("body = %s, want %s", rec.Body.String(), upstreamBody)
+	}
+}
+
+// TestSignResponseStreamed tests that a streamed upstream NACK is relayed with its
+// status although the proxy flushes it.
+func TestSignResponseStreamed(t *testing.T) {
+	const upstreamBody = `{"message":{"ack":{"status":"NACK"}}}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.(http.Flusher).Flush()
+		fmt.Fprint(w, upstreamBody)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	signer, err := newSignStep(&mockSigner{}, &signKeyManager{})
+	if err != nil {
+		t.Fatalf("newSignStep() error = %v", err)
+	}
+	h := &stdHandler{
+		steps:        []definition.Step{&routeStep{route: &model.Route{TargetType: "url", URL: target}}},
+		postSteps:    []definition.Step{&resultStep{}},
+		SubscriberID: "bap.example.com",
+		httpClient:   &http.Client{},
+		respSigner:   signer,
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bap/caller/search", strings.NewReader(asyncTestBody)))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if rec.Body.String() != upstreamBody {
+		t.Errorf("body = %s, want %s", rec.Body.String(), upstreamBody)
+	}
+}
+
+// TestInitResponseSigningFailure tests that response signing requires its plugins.
+func TestInitResponseSigningFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     ResponseSigningConfig
+		wantErr string
+	}{
+		{name: "sign", cfg: ResponseSigningConfig{Sign: true}, wantErr: "Signer plugin not configured"},
+		{name: "verify", cfg: ResponseSigningConfig{Verify: true}, wantErr: "SignValidator plugin not configured"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &stdHandler{httpClient: &http.Client{}}
+			if err := h.initResponseSigning(&tt.cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("initResponseSigning() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// Response steps run on the upstream response of a request proxied to a url route.
+// Their StepContext carries the original request, the response body as Body and the
+// response headers as RespHeader, which the steps may modify.
+
+// validateResponseSignStep verifies that the upstream response is signed by the responder.
+// It is enabled by ResponseSigningConfig.Verify and runs before the configured response steps.
+type validateResponseSignStep struct {
+	verifier *validateSignStep
+}
+
+// newValidateResponseSignStep creates and returns the response signature verification step.
+func newValidateResponseSignStep(signValidator definition.SignValidator, km definition.KeyManager) (definition.Step, error) {
+	verifier, err := newValidateSignStep(signValidator, km, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &validateResponseSignStep{verifier: verifier}, nil
+}
+
+// Run verifies the Authorization header of the response against its body.
+func (s *validateResponseSignStep) Run(ctx *model.StepContext) error {
+	header := ctx.RespHeader.Get(model.AuthHeaderSubscriber)
+	if header == "" {
+		return model.NewBadGatewayErr(fmt.Errorf("upstream response is not signed"))
+	}
+	if err := s.verifier.validate(ctx, ctx.Body, header); err != nil {
+		return model.NewBadGatewayErr(fmt.Errorf("invalid upstream response signature: %w", err))
+	}
+	return nil
+}
+
+// validateAckSchemaStep checks that the upstream response is a Beckn ACK or NACK.
+type validateAckSchemaStep struct{}
+
+// Run validates the response body against the Beckn acknowledgement schema.
+func (s *validateAckSchemaStep) Run(ctx *model.StepContext) error {
+	var resp model.Response
+	dec := json.NewDecoder(bytes.NewReader(ctx.Body))
+	if err := dec.Decode(&resp); err != nil {
+		return model.NewBadGatewayErr(fmt.Errorf("upstream response is not a Beckn acknowledgement: %w", err))
+	}
+	switch resp.Message.Ack.Status {
+	case model.StatusACK:
+		return nil
+	case model.StatusNACK:
+		if resp.Message.Error == nil || resp.Message.Error.Code == "" {
+			return model.NewBadGatewayErr(fmt.Errorf("upstream NACK has no error code"))
+		}
+		return nil
+	default:
+		return model.NewBadGatewayErr(fmt.Errorf("upstream response has invalid ack status %q", resp.Message.Ack.Status))
+	}
+}
+
+// runResponseSteps runs the response steps on an upstream response and restores its
+// body for the caller. Failures that are not Beckn errors are reported as BadGatewayErr.
+func runResponseSteps(ctx *model.StepContext, resp *http.Response, steps []definition.Step) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return model.NewBadGatewayErr(fmt.Errorf("failed to read upstream response: %w", err))
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	respCtx := &model.StepContext{
+		Context:    ctx.Context,
+		Request:    ctx.Request,
+		Body:       body,
+		Route:      ctx.Route,
+		SubID:      ctx.SubID,
+		Role:       ctx.Role,
+		RespHeader: resp.Header,
+	}
+	for _, step := range steps {
+		if err := step.Run(respCtx); err != nil {
+			log.Errorf(ctx, err, "Upstream response with status %d rejected", resp.StatusCode)
+			if hasBecknError(err) {
+				return err
+			}
+			return model.NewBadGatewayErr(err)
+		}
+	}
+	// Steps may change the body.
+	if !bytes.Equal(respCtx.Body, body) {
+		resp.Body = io.NopCloser(bytes.NewReader(respCtx.Body))
+		resp.ContentLength = int64(len(respCtx.Body))
+		resp.Header.Del("Content-Length")
+	}
+	return nil
+}
+
+// hasBecknError reports whether err wraps one of the model errors that SendNack maps
+// to a Beckn error code.
+func hasBecknError(err error) bool {
+	var becknErr interface{ BecknError() *model.Error }
+	return errors.As(err, &becknErr)
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// TestProxyResponseSteps tests that response steps pass valid upstream responses
+// through and replace rejected ones with a NACK.
+func TestProxyResponseSteps(t *testing.T) {
+	validateSign, err := newValidateResponseSignStep(&signatureValidator{}, &mockKeyManager{})
+	if err != nil {
+		t.Fatalf("newValidateResponseSignStep() error = %v", err)
+	}
+	tests := []struct {
+		name       string
+		status     int
+		signature  string
+		body       string
+		steps      []definition.Step
+		wantStatus int
+		wantAck    model.Status
+		wantCode   string
+	}{
+		{
+			name:       "ack",
+			status:     http.StatusOK,
+			body:       `{"message":{"ack":{"status":"ACK"}}}`,
+			steps:      []definition.Step{&validateAckSchemaStep{}},
+			wantStatus: http.StatusOK,
+			wantAck:    model.StatusACK,
+		},
+		{
+			name:       "upstream nack",
+			status:     http.StatusBadRequest,
+			body:       `{"message":{"ack":{"status":"NACK"},"error":{"code":"30000","message":"invalid item"}}}`,
+			steps:      []definition.Step{&validateAckSchemaStep{}},
+			wantStatus: http.StatusBadRequest,
+			wantAck:    model.StatusNACK,
+			wantCode:   "30000",
+		},
+		{
+			name:       "html error page",
+			status:     http.StatusBadGateway,
+			body:       `<html><body>502 Bad Gateway</body></html>`,
+			steps:      []definition.Step{&validateAckSchemaStep{}},
+			wantStatus: http.StatusBadGateway,
+			wantAck:    model.StatusNACK,
+			wantCode:   "Bad Gateway",
+		},
+		{
+			name:       "nack without error",
+			status:     http.StatusOK,
+			body:       `{"message":{"ack":{"status":"NACK"}}}`,
+			steps:      []definition.Step{&validateAckSchemaStep{}},
+			wantStatus: http.StatusBadGateway,
+			wantAck:    model.StatusNACK,
+			wantCode:   "Bad Gateway",
+		},
+		{
+			name:       "signed",
+			status:     http.StatusOK,
+			signature:  "valid",
+			body:       `{"message":{"ack":{"status":"ACK"}}}`,
+			steps:      []definition.Step{validateSign},
+			wantStatus: http.StatusOK,
+			wantAck:    model.StatusACK,
+		},
+		{
+			name:       "unsigned",
+			status:     http.StatusOK,
+			body:       `{"message":{"ack":{"status":"ACK"}}}`,
+			steps:      []definition.Step{validateSign},
+			wantStatus: http.StatusBadGateway,
+			wantAck:    model.StatusNACK,
+			wantCode:   "Bad Gateway",
+		},
+		{
+			name:       "beckn error from step",
+			status:     http.StatusOK,
+			body:       `{"message":{"ack":{"status":"ACK"}}}`,
+			steps:      []definition.Step{&failingStep{err: model.NewBadReqErr(errors.New("bad response"))}},
+			wantStatus: http.StatusBadRequest,
+			wantAck:    model.StatusNACK,
+			wantCode:   "Bad Request",
+		},
+		{
+			name:       "plain error from step",
+			status:     http.StatusOK,
+			body:       `{"message":{"ack":{"status":"ACK"}}}`,
+			steps:      []definition.Step{&failingStep{err: errors.New("step failed")}},
+			wantStatus: http.StatusBadGateway,
+			wantAck:    model.StatusNACK,
+			wantCode:   "Bad Gateway",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.signature != "" {
+					w.Header().Set(model.AuthHeaderSubscriber, fmt.Sprintf(
+						`Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519",signature="%s"`, tt.signature))
+				}
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer srv.Close()
+			target, _ := url.Parse(srv.URL)
+
+			req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", strings.NewReader(asyncTestBody))
+			ctx := &model.StepContext{
+				Context: req.Context(),
+				Request: req,
+				Body:    []byte(asyncTestBody),
+				Route:   &model.Route{TargetType: "url", URL: target},
+			}
+			rec := httptest.NewRecorder()
+			proxy(ctx, req, rec, &http.Client{}, tt.steps)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var resp model.Response
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("body = %s, want a Beckn response: %v", rec.Body.String(), err)
+			}
+			if resp.Message.Ack.Status != tt.wantAck {
+				t.Errorf("ack status = %s, want %s", resp.Message.Ack.Status, tt.wantAck)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			if resp.Message.Error == nil || resp.Message.Error.Code != tt.wantCode {
+				t.Errorf("error = %+v, want code %q", resp.Message.Error, tt.wantCode)
+			}
+		})
+	}
+}
+
+// TestInitResponseStepsFailure tests that unknown response steps are rejected.
+func TestInitResponseStepsFailure(t *testing.T) {
+	tests := []struct {
+		step    string
+		wantErr string
+	}{
+		{step: "unknown", wantErr: "unrecognized response step"},
+		{step: "validateSign", wantErr: "responseSigning.verify"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			h := &stdHandler{}
+			cfg := &Config{ResponseSteps: []string{tt.step}}
+			if err := h.initSteps(t.Context(), nil, cfg); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("initSteps() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+const (
+	// sequenceKeyPrefix namespaces the transaction states in the cache.
+	sequenceKeyPrefix = "txn:"
+
+	// defaultSequenceTTL is how long a transaction is tracked after its last message
+	// if no TTL is configured.
+	defaultSequenceTTL = 24 * time.Hour
+
+	// stageConfirmed is the stage of a transaction once it is confirmed.
+	stageConfirmed = 3
+)
+
+// stages orders the actions of a transaction. An action may only be requested once
+// the transaction has reached the stage before it, so that e.g. confirm needs a prior
+// init. Actions without a stage, such as support or rating, are not checked.
+var stages = map[string]int{
+	"search":  0,
+	"select":  1,
+	"init":    2,
+	"confirm": stageConfirmed,
+	"status":  stageConfirmed + 1,
+	"update":  stageConfirmed + 1,
+	"cancel":  stageConfirmed + 1,
+	"track":   stageConfirmed + 1,
+}
+
+// transactionState is the cache entry of a transaction.
+type transactionState struct {
+	// Stage is the furthest stage that the transaction has reached.
+	Stage int `json:"stage"`
+
+	// Requested holds the actions requested in the transaction, whose callbacks are expected.
+	Requested []string `json:"requested"`
+}
+
+// validateSequenceStep tracks the state of each transaction and checks that its
+// messages follow the order of the Beckn transaction.
+type validateSequenceStep struct {
+	cache    definition.Cache
+	ttl      time.Duration
+	mode     CheckMode
+	domains  map[string]CheckMode
+	patterns []string // Glob domains of domains, most specific first
+}
+
+// sequenceUpdateKey is the context key under which the validateSequence step passes
+// the state that a request moves its transaction to, to be recorded once routed.
+type sequenceUpdateKey struct{}
+
+// sequenceUpdate is the state that a request moves its transaction to.
+type sequenceUpdate struct {
+	key           string
+	transactionID string
+	state         *transactionState
+}
+
+// newValidateSequenceStep creates and returns the validateSequence step.
+func newValidateSequenceStep(cache definition.Cache, cfg *SequenceConfig) (*validateSequenceStep, error) {
+	if cache == nil {
+		return nil, fmt.Errorf("invalid config: validateSequence step requires the Cache plugin")
+	}
+	s := &validateSequenceStep{cache: cache, ttl: cfg.TTL, mode: cfg.Mode, domains: cfg.Domains}
+	if s.ttl <= 0 {
+		s.ttl = defaultSequenceTTL
+	}
+	if s.mode == "" {
+		s.mode = CheckEnforce
+	}
+	if err := validateCheckMode(s.mode); err != nil {
+		return nil, err
+	}
+	for domain, mode := range s.domains {
+		if err := validateCheckMode(mode); err != nil {
+			return nil, fmt.Errorf("domain %s: %w", domain, err)
+		}
+		if _, err := path.Match(domain, ""); err != nil {
+			return nil, fmt.Errorf("domain pattern %q: %w", domain, err)
+		}
+		if strings.ContainsAny(domain, `*?[\`) {
+			s.patterns = append(s.patterns, domain)
+		}
+	}
+	// A longer pattern is taken to be more specific, as in "ONDC:RET1*" over "ONDC:*".
+	slices.SortFunc(s.patterns, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	return s, nil
+}
+
+// modeFor returns the mode of domain: the mode of the domain itself, else of the most
+// specific glob domain matching it, else the default mode.
+func (s *validateSequenceStep) modeFor(domain string) CheckMode {
+	if m, ok := s.domains[domain]; ok {
+		return m
+	}
+	for _, pattern := range s.patterns {
+		if ok, _ := path.Match(pattern, domain); ok {
+			return s.domains[pattern]
+		}
+	}
+	return s.mode
+}
+
+// Run checks the message against the state of its transaction. In warn mode an
+// out-of-order message is logged and processed. The state the message moves its
+// transaction to is recorded by the sequenceAdvanceStep once the message is routed.
+// The lookup and the write are not atomic, so messages of a transaction arriving at
+// the same moment may be checked against the same state.
+func (s *validateSequenceStep) Run(ctx *model.StepContext) error {
+	bc, err := ctx.BecknContext()
+	if err != nil || bc.TransactionID == "" {
+		log.Debugf(ctx, "No transaction_id, skipping sequence check")
+		return nil
+	}
+	mode := s.modeFor(bc.Domain)
+	if mode == CheckOff {
+		return nil
+	}
+	action := conditionContext(ctx).Action
+	key := sequenceKeyPrefix + bc.BAPID + "|" + bc.TransactionID
+
+	var state *transactionState
+	if value, err := s.cache.Get(ctx, key); err == nil {
+		state = &transactionState{}
+		if err := json.Unmarshal([]byte(value), state); err != nil {
+			log.Errorf(ctx, err, "Invalid state of transaction %s, tracking it anew", bc.TransactionID)
+			state = nil
+		}
+	}
+	if err := checkSequence(state, action); err != nil {
+		metrics.ObserveSequenceViolation(ctx, action)
+		err = fmt.Errorf("transaction %s: %w", bc.TransactionID, err)
+		if mode == CheckEnforce {
+			log.Warnf(ctx, "Out-of-order message rejected: %v", err)
+			return model.NewBadReqErr(err)
+		}
+		log.Warnf(ctx, "Out-of-order message: %v", err)
+	}
+
+	if state == nil {
+		state = &transactionState{}
+	}
+	if advance(state, action) {
+		withStepValue(ctx, sequenceUpdateKey{}, &sequenceUpdate{key: key, transactionID: bc.TransactionID, state: state})
+	}
+	return nil
+}
+
+// sequenceAdvanceStep is the post step of the validateSequence step. It records the
+// state that a message moves its transaction to once the message is routed, so that
+// a message rejected by a later step or not delivered does not advance it.
+type sequenceAdvanceStep struct {
+	seq *validateSequenceStep
+}
+
+// Run records the new state of the transaction of a routed message.
+func (s *sequenceAdvanceStep) Run(ctx *model.StepContext) error {
+	update, ok := ctx.Value(sequenceUpdateKey{}).(*sequenceUpdate)
+	if !ok || ctx.RouteResult == nil || ctx.RouteResult.Err != nil {
+		return nil
+	}
+	value, _ := json.Marshal(update.state)
+	if err := s.seq.cache.Set(ctx, update.key, string(value), s.seq.ttl); err != nil {
+		return fmt.Errorf("failed to record state of transaction %s: %w", update.transactionID, err)
+	}
+	return nil
+}
+
+// checkSequence reports whether action may follow the state of its transaction,
+// which is nil for an unknown transaction. A callback needs a prior request for its
+// action, except for the callbacks of confirmed orders that participants may send
+// unsolicited, such as on_status.
+func checkSequence(state *transactionState, action string) error {
+	if req, ok := strings.CutPrefix(action, "on_"); ok {
+		stage, tracked := stages[req]
+		switch {
+		case !tracked:
+			return nil
+		case state != nil && slices.Contains(state.Requested, req):
+			return nil
+		case state != nil && stage > stageConfirmed && state.Stage >= stageConfirmed:
+			return nil
+		default:
+			return fmt.Errorf("%s without a prior %s", action, req)
+		}
+	}
+
+	stage, tracked := stages[action]
+	if !tracked {
+		return nil
+	}
+	reached := -1
+	if state != nil {
+		reached = state.Stage
+	} else if stage <= stages["select"] {
+		// Transactions start with a search, or a select from a known catalog.
+		return nil
+	}
+	if stage > reached+1 {
+		return fmt.Errorf("%s before %s", action, previousAction(stage))
+	}
+	return nil
+}
+
+// previousAction returns the action of the stage before stage.
+func previousAction(stage int) string {
+	prev := stage - 1
+	if prev > stageConfirmed {
+		prev = stageConfirmed
+	}
+	for action, s := range stages {
+		if s == prev {
+			return action
+		}
+	}
+	return ""
+}
+
+// advance records the request of action in state. It reports whether state changed.
+func advance(state *transactionState, action string) bool {
+	stage, tracked := stages[action]
+	if !tracked {
+		return false
+	}
+	changed := false
+	if stage > state.Stage {
+		state.Stage = stage
+		changed = true
+	}
+	if !slices.Contains(state.Requested, action) {
+		state.Requested = append(state.Requested, action)
+		changed = true
+	}
+	return changed
+}
@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// TestCheckSequence tests the order of actions within a transaction.
+func TestCheckSequence(t *testing.T) {
+	selected := &transactionState{Stage: 1, Requested: []string{"search", "select"}}
+	confirmed := &transactionState{Stage: stageConfirmed, Requested: []string{"select", "init", "confirm"}}
+	tests := []struct {
+		name    string
+		state   *transactionState
+		action  string
+		wantErr string
+	}{
+		{name: "new search", action: "search"},
+		{name: "new select", action: "select"},
+		{name: "new init", action: "init", wantErr: "init before select"},
+		{name: "new confirm", action: "confirm", wantErr: "confirm before init"},
+		{name: "init after select", state: selected, action: "init"},
+		{name: "confirm after select", state: selected, action: "confirm", wantErr: "confirm before init"},
+		{name: "status after select", state: selected, action: "status", wantErr: "status before confirm"},
+		{name: "select again", state: selected, action: "select"},
+		{name: "status after confirm", state: confirmed, action: "status"},
+		{name: "untracked action", action: "support"},
+		{name: "requested callback", state: selected, action: "on_select"},
+		{name: "unrequested callback", state: selected, action: "on_init", wantErr: "on_init without a prior init"},
+		{name: "callback of unknown transaction", action: "on_confirm", wantErr: "on_confirm without a prior confirm"},
+		{name: "unsolicited on_status after confirm", state: confirmed, action: "on_status"},
+		{name: "unsolicited on_status before confirm", state: selected, action: "on_status", wantErr: "on_status without a prior status"},
+		{name: "untracked callback", action: "on_support"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSequence(tt.state, tt.action)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("checkSequence() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("checkSequence() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// runSequence runs the validateSequence step for action and, if the message passes,
+// its post step with the result of routing it.
+func runSequence(t *testing.T, s *validateSequenceStep, domain, action string, routeErr error) error {
+	t.Helper()
+	body := `{"context":{"domain":"` + domain + `","action":"` + action + `","bap_id":"bap.example.com","transaction_id":"txn-1"}}`
+	req := httptest.NewRequest(http.MethodPost, "/"+action, strings.NewReader(body))
+	ctx := &model.StepContext{Context: req.Context(), Request: req, Body: []byte(body)}
+	if err := s.Run(ctx); err != nil {
+		return err
+	}
+	ctx.RouteResult = &model.RouteResult{Err: routeErr}
+	if err := (&sequenceAdvanceStep{seq: s}).Run(ctx); err != nil {
+		t.Fatalf("sequenceAdvanceStep.Run(%s) error = %v", action, err)
+	}
+	return nil
+}
+
+// TestValidateSequenceStep tests that the step tracks transactions and rejects or
+// flags out-of-order messages depending on the mode of their domain.
+func TestValidateSequenceStep(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SequenceConfig
+		domain  string
+		actions []string
+		wantErr []bool
+	}{
+		{
+			name:    "in order",
+			actions: []string{"search", "on_search", "select", "on_select", "init", "on_init", "confirm", "on_confirm", "status"},
+			wantErr: []bool{false, false, false, false, false, false, false, false, false},
+		},
+		{
+			name:    "enforce",
+			actions: []string{"select", "confirm", "on_confirm", "init", "confirm"},
+			wantErr: []bool{false, true, true, false, false},
+		},
+		{
+			name:    "warn",
+			cfg:     SequenceConfig{Mode: CheckWarn},
+			actions: []string{"select", "confirm", "on_init"},
+			wantErr: []bool{false, false, false},
+		},
+		{
+			name:    "domain in warn mode",
+			cfg:     SequenceConfig{Domains: map[string]CheckMode{"ONDC:RET10": CheckWarn}},
+			domain:  "ONDC:RET10",
+			actions: []string{"confirm"},
+			wantErr: []bool{false},
+		},
+		{
+			name:    "domain enforced",
+			cfg:     SequenceConfig{Mode: CheckWarn, Domains: map[string]CheckMode{"ONDC:RET10": CheckEnforce}},
+			domain:  "ONDC:RET10",
+			actions: []string{"confirm"},
+			wantErr: []bool{true},
+		},
+		{
+			name:    "domain off",
+			cfg:     SequenceConfig{Domains: map[string]CheckMode{"ONDC:RET10": CheckOff}},
+			domain:  "ONDC:RET10",
+			actions: []string{"confirm", "on_cancel"},
+			wantErr: []bool{false, false},
+		},
+		{
+			name:    "glob domain",
+			cfg:     SequenceConfig{Domains: map[string]CheckMode{"ONDC:RET*": CheckWarn}},
+			domain:  "ONDC:RET10",
+			actions: []string{"confirm"},
+			wantErr: []bool{false},
+		},
+		{
+			name:    "most specific glob domain",
+			cfg:     SequenceConfig{Mode: CheckWarn, Domains: map[string]CheckMode{"ONDC:*": CheckWarn, "ONDC:RET1*": CheckEnforce}},
+			domain:  "ONDC:RET10",
+			actions: []string{"confirm"},
+			wantErr: []bool{true},
+		},
+		{
+			name:    "domain over glob domain",
+			cfg:     SequenceConfig{Domains: map[string]CheckMode{"ONDC:RET*": CheckEnforce, "ONDC:RET10": CheckOff}},
+			domain:  "ONDC:RET10",
+			actions: []string{"confirm"},
+			wantErr: []bool{false},
+		},
+		{
+			name:    "glob domain not matching",
+			cfg:     SequenceConfig{Domains: map[string]CheckMode{"ONDC:TRV*": CheckOff}},
+			domain:  "ONDC:RET10",
+			actions: []string{"confirm"},
+			wantErr: []bool{true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newMockCache()
+			s, err := newValidateSequenceStep(cache, &tt.cfg)
+			if err != nil {
+				t.Fatalf("newValidateSequenceStep() error = %v", err)
+			}
+			for i, action := range tt.actions {
+				err := runSequence(t, s, tt.domain, action, nil)
+				if gotErr := err != nil; gotErr != tt.wantErr[i] {
+					t.Fatalf("Run(%s) error = %v, want error %v", action, err, tt.wantErr[i])
+				}
+				var badReq *model.BadReqErr
+				if err != nil && !errors.As(err, &badReq) {
+					t.Errorf("Run(%s) error = %T, want *model.BadReqErr", action, err)
+				}
+			}
+		})
+	}
+}
+
+// TestValidateSequenceStepState tests the transaction state recorded in the cache.
+func TestValidateSequenceStepState(t *testing.T) {
+	cache := newMockCache()
+	s, err := newValidateSequenceStep(cache, &SequenceConfig{TTL: time.Hour})
+	if err != nil {
+		t.Fatalf("newValidateSequenceStep() error = %v", err)
+	}
+	for _, action := range []string{"select", "init"} {
+		if err := runSequence(t, s, "", action, nil); err != nil {
+			t.Fatalf("Run(%s) error = %v", action, err)
+		}
+	}
+	// A confirm that is not delivered does not advance the transaction.
+	if err := runSequence(t, s, "", "confirm", errors.New("upstream unavailable")); err != nil {
+		t.Fatalf("Run(confirm) error = %v", err)
+	}
+	key := sequenceKeyPrefix + "bap.example.com|txn-1"
+	if want := `{"stage":2,"requested":["select","init"]}`; cache.data[key] != want {
+		t.Errorf("state = %s, want %s", cache.data[key], want)
+	}
+	if cache.ttls[key] != time.Hour {
+		t.Errorf("ttl = %v, want %v", cache.ttls[key], time.Hour)
+	}
+}
+
+// TestValidateSequenceAdvance tests that the handler advances a transaction only
+// for messages that pass all steps and are routed.
+func TestValidateSequenceAdvance(t *testing.T) {
+	cache := newMockCache()
+	h := &stdHandler{cache: cache}
+	if err := h.initSteps(context.Background(), nil, &Config{Steps: []StepConfig{{Name: "validateSequence"}}}); err != nil {
+		t.Fatalf("initSteps() error = %v", err)
+	}
+	if len(h.postSteps) != 1 {
+		t.Fatalf("post steps = %d, want the post step of validateSequence", len(h.postSteps))
+	}
+	body := `{"context":{"action":"select","bap_id":"bap.example.com","transaction_id":"txn-1"}}`
+	key := sequenceKeyPrefix + "bap.example.com|txn-1"
+
+	h.steps = append(h.steps, &failingStep{err: model.NewBadReqErr(errors.New("invalid"))})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bpp/receiver/select", strings.NewReader(body)))
+	if _, ok := cache.data[key]; ok {
+		t.Errorf("state = %s after a rejected message, want none", cache.data[key])
+	}
+
+	h.steps = h.steps[:1]
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bpp/receiver/select", strings.NewReader(body)))
+	if want := `{"stage":1,"requested":["select"]}`; cache.data[key] != want {
+		t.Errorf("state = %s, want %s", cache.data[key], want)
+	}
+}
+
+// TestNewValidateSequenceStepFailure tests that invalid configs are rejected.
+func TestNewValidateSequenceStepFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     SequenceConfig
+		noCache bool
+		wantErr string
+	}{
+		{name: "no cache", noCache: true, wantErr: "requires the Cache plugin"},
+		{name: "invalid mode", cfg: SequenceConfig{Mode: "strict"}, wantErr: `invalid mode "strict"`},
+		{name: "invalid domain mode", cfg: SequenceConfig{Domains: map[string]CheckMode{"ONDC:RET10": "log"}}, wantErr: "domain ONDC:RET10"},
+		{name: "invalid domain pattern", cfg: SequenceConfig{Domains: map[string]CheckMode{"ONDC:[RET": CheckWarn}}, wantErr: `domain pattern "ONDC:[RET"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cache definition.Cache = newMockCache()
+			if tt.noCache {
+				cache = nil
+			}
+			_, err := newValidateSequenceStep(cache, &tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newValidateSequenceStep() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -3,22 +3,34 @@ package handler
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httputil"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	"github.com/beckn-one/beckn-onix/pkg/plugin"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
 	"github.com/beckn-one/beckn-onix/pkg/response"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 )
 
 // stdHandler orchestrates the execution of defined processing steps.
 type stdHandler struct {
 	signer          definition.Signer
 	steps           []definition.Step
+	respSteps       []definition.Step // respSteps run on the upstream responses of url routes.
+	postSteps       []definition.Step // postSteps run after routing.
 	signValidator   definition.SignValidator
 	cache           definition.Cache
 	registry        definition.RegistryLookup
@@ -29,6 +41,11 @@ type stdHandler struct {
 	SubscriberID    string
 	role            model.Role
 	httpClient      *http.Client
+	async           *asyncDispatcher
+	ackPolicy       AckPolicy
+	respSigner      *signStep        // respSigner signs the responses of the handler; nil disables it.
+	idempotency     *idempotencyStep // idempotency records the responses to new messages; nil if the step is not configured.
+	checkers        map[string]definition.HealthChecker
 }
 
 // newHTTPClient creates a new HTTP client with a custom transport configuration.
@@ -50,7 +67,7 @@ func newHTTPClient(cfg *HttpClientConfig) *http.Client {
 	if cfg.ResponseHeaderTimeout > 0 {
 		transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
 	}
-	return &http.Client{Transport: transport}
+	return &http.Client{Transport: newTransport(transport, cfg)}
 }
 
 // NewStdHandler initializes a new processor with plugins and steps.
@@ -60,6 +77,14 @@ func NewStdHandler(ctx context.Context, mgr PluginManager, cfg *Config) (http.Ha
 		SubscriberID: cfg.SubscriberID,
 		role:         cfg.Role,
 		httpClient:   newHTTPClient(&cfg.HttpClientConfig),
+		ackPolicy:    cfg.Broadcast.AckPolicy,
+	}
+	switch h.ackPolicy {
+	case "":
+		h.ackPolicy = AckPolicyAny
+	case AckPolicyAll, AckPolicyAny, AckPolicyNone:
+	default:
+		return nil, fmt.Errorf("invalid broadcast ackPolicy %q: must be all, any or none", h.ackPolicy)
 	}
 	// Initialize plugins.
 	if err := h.initPlugins(ctx, mgr, &cfg.Plugins); err != nil {
@@ -69,50 +94,182 @@ func NewStdHandler(ctx context.Context, mgr PluginManager, cfg *Config) (http.Ha
 	if err := h.initSteps(ctx, mgr, cfg); err != nil {
 		return nil, fmt.Errorf("failed to initialize steps: %w", err)
 	}
+	if err := h.initResponseSigning(&cfg.ResponseSigning); err != nil {
+		return nil, fmt.Errorf("failed to initialize response signing: %w", err)
+	}
+	if cfg.Async.Enabled {
+		h.async = newAsyncDispatcher(ctx, &cfg.Async, h.httpClient, h.publisher)
+	}
 	return h, nil
 }
 
+// initResponseSigning sets up the signing of responses sent by the handler and the
+// verification of the upstream responses of url routes, which runs before the
+// other response steps.
+func (h *stdHandler) initResponseSigning(cfg *ResponseSigningConfig) error {
+	if cfg.Sign {
+		signer, err := newSignStep(h.signer, h.km)
+		if err != nil {
+			return err
+		}
+		h.respSigner = signer
+	}
+	if cfg.Verify {
+		verifier, err := newValidateResponseSignStep(h.signValidator, h.km)
+		if err != nil {
+			return err
+		}
+		h.respSteps = append([]definition.Step{&instrumentedStep{name: "response validateSign", step: verifier}}, h.respSteps...)
+	}
+	return nil
+}
+
+// Close stops the asynchronous delivery of the handler once the queued requests
+// have been delivered. It is called after the handler stops receiving requests.
+func (h *stdHandler) Close() {
+	if h.async != nil {
+		h.async.close()
+	}
+}
+
+// CheckDependencies runs the health checks of the plugins that implement
+// definition.HealthChecker concurrently and returns their results by plugin name.
+func (h *stdHandler) CheckDependencies(ctx context.Context) map[string]error {
+	results := make(map[string]error, len(h.checkers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, hc := range h.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := hc.HealthCheck(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// addChecker registers p for readiness checks if it implements definition.HealthChecker.
+func (h *stdHandler) addChecker(name string, p any) {
+	if hc, ok := p.(definition.HealthChecker); ok {
+		if h.checkers == nil {
+			h.checkers = map[string]definition.HealthChecker{}
+		}
+		h.checkers[name] = hc
+	}
+}
+
 // ServeHTTP processes an incoming HTTP request and executes defined processing steps.
+// The response is signed and flushed to the caller before the post steps run.
 func (h *stdHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	out := w
+	var sw *signingResponseWriter
+	if h.respSigner != nil {
+		sw = &signingResponseWriter{ResponseWriter: w}
+		w = sw
+	}
+	var rw *recordingResponseWriter
+	if h.idempotency != nil {
+		rw = &recordingResponseWriter{ResponseWriter: w}
+		w = rw
+	}
+	var stw *statusResponseWriter
+	if len(h.postSteps) > 0 {
+		stw = &statusResponseWriter{ResponseWriter: w}
+		w = stw
+	}
 	ctx, err := h.stepCtx(r, w.Header())
 	if err != nil {
 		log.Errorf(r.Context(), err, "stepCtx(r):%v", err)
 		response.SendNack(r.Context(), w, err)
+		if sw != nil {
+			signResponse(r.Context(), h.respSigner, h.subID(r.Context()), sw)
+		}
 		return
 	}
 	log.Request(r.Context(), r, ctx.Body)
 
-	// Execute processing steps.
+	result := h.process(ctx, r, w)
+	if rw != nil {
+		h.idempotency.record(ctx, rw)
+	}
+	if sw != nil {
+		signResponse(ctx, h.respSigner, h.subID(ctx), sw)
+	}
+	if stw == nil {
+		return
+	}
+	// The caller has its response and need not wait for the post steps.
+	if err := http.NewResponseController(out).Flush(); err != nil {
+		log.Debugf(ctx, "Response not flushed before post steps: %v", err)
+	}
+	result.Status = stw.status
+	ctx.RouteResult = result
+	h.runPostSteps(ctx)
+}
+
+// process runs the processing steps and routes the request, writing the ACK or NACK
+// to w, and returns the outcome for the post steps.
+func (h *stdHandler) process(ctx *model.StepContext, r *http.Request, w http.ResponseWriter) *model.RouteResult {
 	for _, step := range h.steps {
 		if err := step.Run(ctx); err != nil {
+			var dup *duplicateMessage
+			if errors.As(err, &dup) {
+				dup.respond(w)
+				return &model.RouteResult{Err: err}
+			}
 			log.Errorf(ctx, err, "%T.run(%v):%v", step, ctx, err)
 			response.SendNack(ctx, w, err)
-			return
+			return &model.RouteResult{Err: err}
 		}
 	}
 	// Restore request body before forwarding or publishing.
 	r.Body = io.NopCloser(bytes.NewReader(ctx.Body))
 	if ctx.Route == nil {
 		response.SendAck(w)
-		return
+		return &model.RouteResult{}
 	}
+	start := time.Now()
+	var status int
+	var err error
+	if h.async != nil {
+		if err = h.async.enqueue(ctx, r); err != nil {
+			log.Errorf(ctx, err, "Failed to queue request for async delivery")
+			response.SendNack(ctx, w, err)
+		} else {
+			response.SendAck(w)
+		}
+	} else {
+		// Handle routing based on the defined route type.
+		status, err = route(ctx, r, w, h.publisher, h.httpClient, h.ackPolicy, h.respSteps)
+	}
+	return &model.RouteResult{UpstreamStatus: status, Latency: time.Since(start), Err: err}
+}
 
-	// Handle routing based on the defined route type.
-	route(ctx, r, w, h.publisher, h.httpClient)
+// runPostSteps runs the post-routing steps. The caller already has its response,
+// so a failing step is logged and the remaining steps still run.
+func (h *stdHandler) runPostSteps(ctx *model.StepContext) {
+	for _, step := range h.postSteps {
+		if err := step.Run(ctx); err != nil {
+			log.Errorf(ctx, err, "%T.run(%v):%v", step, ctx, err)
+		}
+	}
 }
 
 // stepCtx creates a new StepContext for processing an HTTP request.
 func (h *stdHandler) stepCtx(r *http.Request, rh http.Header) (*model.StepContext, error) {
-	var bodyBuffer bytes.Buffer
-	if _, err := io.Copy(&bodyBuffer, r.Body); err != nil {
+	body, err := model.ReadBody(r)
+	if err != nil {
 		return nil, model.NewBadReqErr(err)
 	}
-	r.Body.Close()
 	subID := h.subID(r.Context())
 	return &model.StepContext{
 		Context:    r.Context(),
 		Request:    r,
-		Body:       bodyBuffer.Bytes(),
+		Body:       body,
 		Role:       h.role,
 		SubID:      subID,
 		RespHeader: rh,
@@ -131,52 +288,116 @@ func (h *stdHandler) subID(ctx context.Context) string {
 var proxyFunc = proxy
 
 // route handles request forwarding or message publishing based on the routing type.
-func route(ctx *model.StepContext, r *http.Request, w http.ResponseWriter, pb definition.Publisher, httpClient *http.Client) {
+// It returns the status of the upstream response for url and broadcast routes and
+// the error, if any, that prevented the request from being delivered.
+func route(ctx *model.StepContext, r *http.Request, w http.ResponseWriter, pb definition.Publisher, httpClient *http.Client, policy AckPolicy, respSteps []definition.Step) (int, error) {
 	log.Debugf(ctx, "Routing to ctx.Route to %#v", ctx.Route)
 	switch ctx.Route.TargetType {
 	case "url":
 		log.Infof(ctx.Context, "Forwarding request to URL: %s", ctx.Route.URL)
-		proxyFunc(ctx, r, w, httpClient)
-		return
+		return proxyFunc(ctx, r, w, httpClient, respSteps)
+	case "broadcast":
+		log.Infof(ctx.Context, "Broadcasting request to %d URLs", len(ctx.Route.URLs))
+		return broadcast(ctx, r, w, httpClient, policy)
 	case "publisher":
 		if pb == nil {
 			err := fmt.Errorf("publisher plugin not configured")
 			log.Errorf(ctx.Context, err, "Invalid configuration:%v", err)
 			response.SendNack(ctx, w, err)
-			return
+			return 0, err
 		}
 		log.Infof(ctx.Context, "Publishing message to: %s", ctx.Route.PublisherID)
-		if err := pb.Publish(ctx, ctx.Route.PublisherID, ctx.Body); err != nil {
+		if err := publish(ctx, pb, ctx.Route.PublisherID, ctx.Body); err != nil {
 			log.Errorf(ctx.Context, err, "Failed to publish message")
 			http.Error(w, "Error publishing message", http.StatusInternalServerError)
 			response.SendNack(ctx, w, err)
-			return
+			return 0, err
 		}
 	default:
 		err := fmt.Errorf("unknown route type: %s", ctx.Route.TargetType)
 		log.Errorf(ctx.Context, err, "Invalid configuration:%v", err)
 		response.SendNack(ctx, w, err)
-		return
+		return 0, err
 	}
 	response.SendAck(w)
+	return 0, nil
+}
+
+// publish sends the message to the publisher in a span for the published hop.
+func publish(ctx context.Context, pb definition.Publisher, publisherID string, msg []byte) error {
+	spanCtx, span := tracing.Start(ctx, "publish "+publisherID,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", publisherID)))
+	err := pb.Publish(spanCtx, publisherID, msg)
+	tracing.End(span, err)
+	return err
 }
-func proxy(ctx *model.StepContext, r *http.Request, w http.ResponseWriter, httpClient *http.Client) {
+
+// proxy forwards the request to the url route and copies the upstream response back.
+// If respSteps are set they run on the upstream response first, and a response
+// they reject is replaced with a NACK. It returns the status of the upstream response,
+// or 0 if there was none, and the error that failed the request.
+func proxy(ctx *model.StepContext, r *http.Request, w http.ResponseWriter, httpClient *http.Client, respSteps []definition.Step) (int, error) {
 	target := ctx.Route.URL
 	r.Header.Set("X-Forwarded-Host", r.Host)
 
+	spanCtx, span := tracing.Start(ctx, "proxy "+target.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("url.full", target.String())))
+	var proxyErr error
+	var status int
+	defer func() { tracing.End(span, proxyErr) }()
+
+	// The transport may still be writing the body after the upstream has responded,
+	// and so after the request completes, so it must not read the pooled buffer of ctx.Body.
+	body := bytes.Clone(ctx.Body)
 	director := func(req *http.Request) {
 		req.URL = target
 		req.Host = target.Host
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		// Allow the transport to replay the body when retrying.
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+		tracing.Inject(req.Context(), propagation.HeaderCarrier(req.Header))
 
 		log.Request(req.Context(), req, ctx.Body)
 	}
 
+	start := time.Now()
 	proxy := &httputil.ReverseProxy{
 		Director:  director,
 		Transport: httpClient.Transport,
+		ModifyResponse: func(resp *http.Response) error {
+			metrics.ObserveOutbound(target.Host, resp.StatusCode, time.Since(start))
+			status = resp.StatusCode
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if resp.StatusCode >= http.StatusInternalServerError {
+				proxyErr = fmt.Errorf("upstream returned status %d", resp.StatusCode)
+			}
+			if len(respSteps) > 0 {
+				if err := runResponseSteps(ctx, resp, respSteps); err != nil {
+					return err
+				}
+			}
+			markRelayed(w)
+			return nil
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			metrics.ObserveOutbound(target.Host, 0, time.Since(start))
+			proxyErr = err
+			// Responses rejected by the response steps already carry their Beckn error.
+			if hasBecknError(err) {
+				response.SendNack(ctx, w, err)
+				return
+			}
+			log.Errorf(ctx, err, "Failed to forward request to %s", target)
+			response.SendNack(ctx, w, model.NewUnavailableErr(err))
+		},
 	}
 
-	proxy.ServeHTTP(w, r)
+	proxy.ServeHTTP(w, r.WithContext(spanCtx))
+	return status, proxyErr
 }
 
 // loadPlugin is a generic function to load and validate plugins.
@@ -245,6 +466,15 @@ func (h *stdHandler) initPlugins(ctx context.Context, mgr PluginManager, cfg *Pl
 		return err
 	}
 
+	h.addChecker("cache", h.cache)
+	h.addChecker("registry", h.registry)
+	h.addChecker("keyManager", h.km)
+	h.addChecker("signValidator", h.signValidator)
+	h.addChecker("schemaValidator", h.schemaValidator)
+	h.addChecker("router", h.router)
+	h.addChecker("publisher", h.publisher)
+	h.addChecker("signer", h.signer)
+
 	log.Debugf(ctx, "All required plugins successfully loaded for stdHandler")
 	return nil
 }
@@ -260,10 +490,12 @@ func (h *stdHandler) initSteps(ctx context.Context, mgr PluginManager, cfg *Conf
 			return fmt.Errorf("failed to initialize plugin step %s: %w", c.ID, err)
 		}
 		steps[c.ID] = step
+		h.addChecker(c.ID, step)
 	}
 
 	// Register processing steps
-	for _, step := range cfg.Steps {
+	for _, sc := range cfg.Steps {
+		step := sc.Name
 		var s definition.Step
 		var err error
 
@@ -271,11 +503,33 @@ func (h *stdHandler) initSteps(ctx context.Context, mgr PluginManager, cfg *Conf
 		case "sign":
 			s, err = newSignStep(h.signer, h.km)
 		case "validateSign":
-			s, err = newValidateSignStep(h.signValidator, h.km)
+			var cache definition.Cache
+			if cfg.ReplayProtection {
+				if h.cache == nil {
+					return fmt.Errorf("invalid config: replayProtection requires the Cache plugin")
+				}
+				cache = h.cache
+			}
+			s, err = newValidateSignStep(h.signValidator, h.km, cache)
 		case "validateSchema":
 			s, err = newValidateSchemaStep(h.schemaValidator)
 		case "addRoute":
-			s, err = newAddRouteStep(h.router)
+			s, err = newAddRouteStep(h.router, h.registry)
+		case "addGatewayRoute":
+			s, err = newAddGatewayRouteStep(h.registry)
+		case "idempotency":
+			h.idempotency, err = newIdempotencyStep(h.cache, &cfg.Idempotency)
+			s = h.idempotency
+		case "validateSequence":
+			var seq *validateSequenceStep
+			if seq, err = newValidateSequenceStep(h.cache, &cfg.Sequence); err == nil {
+				s = seq
+				h.postSteps = append(h.postSteps, &instrumentedStep{name: "post validateSequence", step: &sequenceAdvanceStep{seq: seq}})
+			}
+		case "expectCallback":
+			s, err = newExpectCallbackStep(h.cache, &cfg.Callbacks)
+		case "validateCallback":
+			s, err = newValidateCallbackStep(h.cache, &cfg.Callbacks)
 		default:
 			if customStep, exists := steps[step]; exists {
 				s = customStep
@@ -287,8 +541,55 @@ func (h *stdHandler) initSteps(ctx context.Context, mgr PluginManager, cfg *Conf
 		if err != nil {
 			return err
 		}
+		s = &instrumentedStep{name: step, step: s}
+		if sc.When != nil || sc.Unless != nil {
+			s = &conditionalStep{name: step, step: s, when: sc.When, unless: sc.Unless}
+		}
 		h.steps = append(h.steps, s)
 	}
-	log.Infof(ctx, "Processor steps initialized: %v", cfg.Steps)
+	if h.idempotency != nil && !slices.Contains(stepNames(cfg.Steps), "validateSign") {
+		return fmt.Errorf("invalid config: idempotency step requires the validateSign step")
+	}
+	log.Infof(ctx, "Processor steps initialized: %v", stepNames(cfg.Steps))
+
+	// Register response steps
+	for _, step := range cfg.ResponseSteps {
+		var s definition.Step
+		var err error
+
+		switch step {
+		case "validateSign":
+			// Verified by responseSigning.verify, so that it cannot run twice.
+			err = fmt.Errorf("response step validateSign is configured with responseSigning.verify")
+		case "validateSchema":
+			s = &validateAckSchemaStep{}
+		default:
+			if customStep, exists := steps[step]; exists {
+				s = customStep
+			} else {
+				return fmt.Errorf("unrecognized response step: %s", step)
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+		h.respSteps = append(h.respSteps, &instrumentedStep{name: "response " + step, step: s})
+	}
+	if len(h.respSteps) > 0 {
+		log.Infof(ctx, "Response steps initialized: %v", cfg.ResponseSteps)
+	}
+
+	// Register post-routing steps. Only plugin steps can observe the route result.
+	for _, step := range cfg.PostSteps {
+		s, exists := steps[step]
+		if !exists {
+			return fmt.Errorf("unrecognized post step: %s", step)
+		}
+		h.postSteps = append(h.postSteps, &instrumentedStep{name: "post " + step, step: s})
+	}
+	if len(cfg.PostSteps) > 0 {
+		log.Infof(ctx, "Post steps initialized: %v", cfg.PostSteps)
+	}
 	return nil
 }
@@ -1,9 +1,25 @@
 package handler
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 )
 
 func TestNewHTTPClient(t *testing.T) {
@@ -150,4 +166,251 @@ func TestHttpClientConfigPerformanceValues(t *testing.T) {
 	if transport.ResponseHeaderTimeout != 5*time.Second {
 		t.Errorf("Expected ResponseHeaderTimeout=5s, got %v", transport.ResponseHeaderTimeout)
 	}
-}
\ No newline at end of file
+}
+
+// TestProxyTracePropagation tests that the proxied hop gets its own span and forwards traceparent.
+func TestProxyTracePropagation(t *testing.T) {
+	if _, err := tracing.Init(context.Background(), tracing.Config{}); err != nil {
+		t.Fatalf("tracing.Init() error = %v", err)
+	}
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	traceparent := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent <- r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	target, _ := url.Parse(srv.URL)
+
+	parentCtx, parent := tracing.Start(context.Background(), "module")
+	req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", bytes.NewBufferString(asyncTestBody)).WithContext(parentCtx)
+	ctx := &model.StepContext{
+		Context: parentCtx,
+		Request: req,
+		Body:    []byte(asyncTestBody),
+		Route:   &model.Route{TargetType: "url", URL: target},
+	}
+	proxy(ctx, req, httptest.NewRecorder(), newHTTPClient(&HttpClientConfig{}), nil)
+	parent.End()
+
+	spans := rec.Ended()
+	var hop sdktrace.ReadOnlySpan
+	for _, s := range spans {
+		if s.Name() == "proxy "+target.Host {
+			hop = s
+		}
+	}
+	if hop == nil {
+		t.Fatalf("no span recorded for the proxied hop, got %d spans", len(spans))
+	}
+	if hop.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("proxy span parent = %v, want %v", hop.Parent().SpanID(), parent.SpanContext().SpanID())
+	}
+	if hop.SpanKind() != trace.SpanKindClient {
+		t.Errorf("proxy span kind = %v, want %v", hop.SpanKind(), trace.SpanKindClient)
+	}
+	want := "00-" + hop.SpanContext().TraceID().String() + "-" + hop.SpanContext().SpanID().String() + "-01"
+	if got := <-traceparent; got != want {
+		t.Errorf("forwarded traceparent = %q, want %q", got, want)
+	}
+}
+
+// TestProxyBodyOutlivesRequest tests that the body a transport reads after the upstream
+// responded is not affected by reuse of the buffer of the request body.
+func TestProxyBodyOutlivesRequest(t *testing.T) {
+	var sent, replayed io.Reader
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		// Respond before the body is written, as an upstream rejecting early does.
+		sent = r.Body
+		body, err := r.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		replayed = body
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: r}, nil
+	})}
+	target, _ := url.Parse("http://bpp.example.com/search")
+	buf := []byte(asyncTestBody)
+	req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", bytes.NewReader(buf))
+	ctx := &model.StepContext{
+		Context: req.Context(),
+		Request: req,
+		Body:    buf,
+		Route:   &model.Route{TargetType: "url", URL: target},
+	}
+	if _, err := proxy(ctx, req, httptest.NewRecorder(), client, nil); err != nil {
+		t.Fatalf("proxy() error = %v", err)
+	}
+	// The buffer is reused by another request.
+	copy(buf, bytes.Repeat([]byte("x"), len(buf)))
+
+	for name, r := range map[string]io.Reader{"body": sent, "GetBody": replayed} {
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("reading %s: %v", name, err)
+		}
+		if string(got) != asyncTestBody {
+			t.Errorf("%s = %q, want %q", name, got, asyncTestBody)
+		}
+	}
+}
+
+// validatedStep records whether the request had passed signature validation when it ran.
+type validatedStep struct {
+	validated bool
+}
+
+// Run records whether the signature of the request was validated.
+func (s *validatedStep) Run(ctx *model.StepContext) error {
+	s.validated = signValidated(ctx)
+	return nil
+}
+
+// TestStepTraceHierarchy tests that every step span is a child of the request span,
+// including after steps that add values to the request context.
+func TestStepTraceHierarchy(t *testing.T) {
+	if _, err := tracing.Init(context.Background(), tracing.Config{}); err != nil {
+		t.Fatalf("tracing.Init() error = %v", err)
+	}
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+	parentCtx, parent := tracing.Start(context.Background(), "module")
+	check := &validatedStep{}
+	steps := []definition.Step{
+		&instrumentedStep{name: "validateSign", step: signValidatedStep{}},
+		&instrumentedStep{name: "check", step: check},
+	}
+	ctx := &model.StepContext{Context: parentCtx}
+	for _, step := range steps {
+		if err := step.Run(ctx); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+	parent.End()
+
+	if !check.validated {
+		t.Error("later step did not see the value added by validateSign")
+	}
+	if !signValidated(ctx) {
+		t.Error("request context lost the value added by validateSign")
+	}
+	if got := trace.SpanFromContext(ctx.Context).SpanContext().SpanID(); got != parent.SpanContext().SpanID() {
+		t.Errorf("span of the request context = %v, want %v", got, parent.SpanContext().SpanID())
+	}
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	for _, s := range spans {
+		if s.Name() == "module" {
+			continue
+		}
+		if s.Parent().SpanID() != parent.SpanContext().SpanID() {
+			t.Errorf("%s span parent = %v, want %v", s.Name(), s.Parent().SpanID(), parent.SpanContext().SpanID())
+		}
+	}
+}
+
+// resultStep records the route result it observes and whether the response to the
+// caller had been flushed by then.
+type resultStep struct {
+	w       *httptest.ResponseRecorder
+	result  *model.RouteResult
+	flushed bool
+}
+
+// Run records the route result of the request.
+func (s *resultStep) Run(ctx *model.StepContext) error {
+	s.result = ctx.RouteResult
+	s.flushed = s.w != nil && s.w.Flushed
+	return nil
+}
+
+// failingPublisher fails every publish.
+type failingPublisher struct{}
+
+func (p *failingPublisher) Publish(ctx context.Context, topic string, msg []byte) error {
+	return errors.New("broker unavailable")
+}
+
+// TestPostSteps tests that post steps run after the response is sent, with the
+// outcome of the request.
+func TestPostSteps(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/fail") {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"message":{"ack":{"status":"ACK"}}}`))
+	}))
+	defer srv.Close()
+	okURL, _ := url.Parse(srv.URL + "/search")
+	failURL, _ := url.Parse(srv.URL + "/fail")
+
+	tests := []struct {
+		name         string
+		steps        []definition.Step
+		route        *model.Route
+		wantStatus   int
+		wantUpstream int
+		wantErr      bool
+		wantLatency  bool
+	}{
+		{name: "url", route: &model.Route{TargetType: "url", URL: okURL}, wantStatus: http.StatusOK, wantUpstream: http.StatusOK, wantLatency: true},
+		{name: "url failure", route: &model.Route{TargetType: "url", URL: failURL}, wantStatus: http.StatusInternalServerError, wantUpstream: http.StatusInternalServerError, wantErr: true, wantLatency: true},
+		{name: "publish failure", route: &model.Route{TargetType: "publisher", PublisherID: "topic"}, wantStatus: http.StatusInternalServerError, wantErr: true, wantLatency: true},
+		{name: "broadcast", route: &model.Route{TargetType: "broadcast", URLs: []*url.URL{failURL, okURL}}, wantStatus: http.StatusOK, wantUpstream: http.StatusOK, wantLatency: true},
+		{name: "broadcast failure", route: &model.Route{TargetType: "broadcast", URLs: []*url.URL{failURL}}, wantStatus: http.StatusServiceUnavailable, wantUpstream: http.StatusInternalServerError, wantErr: true, wantLatency: true},
+		{name: "step failure", steps: []definition.Step{&failingStep{err: model.NewBadReqErr(errors.New("invalid"))}}, route: &model.Route{TargetType: "url", URL: okURL}, wantStatus: http.StatusBadRequest, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rec := &resultStep{w: w}
+			h := &stdHandler{
+				httpClient: newHTTPClient(&HttpClientConfig{}),
+				publisher:  &failingPublisher{},
+				ackPolicy:  AckPolicyAny,
+				steps:      append(tt.steps, &routeStep{route: tt.route}),
+				postSteps:  []definition.Step{&failingStep{err: errors.New("audit failed")}, rec},
+			}
+			req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", bytes.NewBufferString(asyncTestBody))
+			h.ServeHTTP(w, req)
+
+			if rec.result == nil {
+				t.Fatal("RouteResult = nil, want the outcome of the request")
+			}
+			if !rec.flushed {
+				t.Error("post steps ran before the response was flushed")
+			}
+			if rec.result.Status != tt.wantStatus || w.Code != tt.wantStatus {
+				t.Errorf("Status = %d, response status = %d, want %d", rec.result.Status, w.Code, tt.wantStatus)
+			}
+			if rec.result.UpstreamStatus != tt.wantUpstream {
+				t.Errorf("UpstreamStatus = %d, want %d", rec.result.UpstreamStatus, tt.wantUpstream)
+			}
+			if (rec.result.Err != nil) != tt.wantErr {
+				t.Errorf("Err = %v, want error %v", rec.result.Err, tt.wantErr)
+			}
+			if (rec.result.Latency > 0) != tt.wantLatency {
+				t.Errorf("Latency = %v, want a positive duration %v", rec.result.Latency, tt.wantLatency)
+			}
+		})
+	}
+}
+
+// TestInitPostStepsFailure tests that post steps must be plugin steps.
+func TestInitPostStepsFailure(t *testing.T) {
+	h := &stdHandler{}
+	cfg := &Config{PostSteps: []string{"validateSign"}}
+	if err := h.initSteps(context.Background(), nil, cfg); err == nil || !strings.Contains(err.Error(), "unrecognized post step") {
+		t.Errorf("initSteps() error = %v, want unrecognized post step", err)
+	}
+}
@@ -2,23 +2,35 @@ package handler
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
+	"path"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 )
 
+// defaultSignatureTTL is the validity period of generated signatures when the
+// signer does not configure one.
+const defaultSignatureTTL = 5 * time.Minute
+
 // signStep represents the signing step in the processing pipeline.
 type signStep struct {
 	signer definition.Signer
 	km     definition.KeyManager
+	ttl    time.Duration
 }
 
 // newSignStep initializes and returns a new signing step.
-func newSignStep(signer definition.Signer, km definition.KeyManager) (definition.Step, error) {
+func newSignStep(signer definition.Signer, km definition.KeyManager) (*signStep, error) {
 	if signer == nil {
 		return nil, fmt.Errorf("invalid config: Signer plugin not configured")
 	}
@@ -26,7 +38,11 @@ func newSignStep(signer definition.Signer, km definition.KeyManager) (definition
 		return nil, fmt.Errorf("invalid config: KeyManager plugin not configured")
 	}
 
-	return &signStep{signer: signer, km: km}, nil
+	ttl := defaultSignatureTTL
+	if p, ok := signer.(definition.TTLProvider); ok && p.TTL() > 0 {
+		ttl = p.TTL()
+	}
+	return &signStep{signer: signer, km: km, ttl: ttl}, nil
 }
 
 // Run executes the signing step.
@@ -34,19 +50,12 @@ func (s *signStep) Run(ctx *model.StepContext) error {
 	if len(ctx.SubID) == 0 {
 		return model.NewBadReqErr(fmt.Errorf("subscriberID not set"))
 	}
-	keySet, err := s.km.Keyset(ctx, ctx.SubID)
-	if err != nil {
-		return fmt.Errorf("failed to get signing key: %w", err)
-	}
-	createdAt := time.Now().Unix()
-	validTill := time.Now().Add(5 * time.Minute).Unix()
-	sign, err := s.signer.Sign(ctx, ctx.Body, keySet.SigningPrivate, createdAt, validTill)
+	authHeader, err := s.sign(ctx, ctx.SubID, ctx.Body)
 	if err != nil {
-		return fmt.Errorf("failed to sign request: %w", err)
+		return err
 	}
-
-	authHeader := s.generateAuthHeader(ctx.SubID, keySet.UniqueKeyID, createdAt, validTill, sign)
-	log.Debugf(ctx, "Signature generated: %v", sign)
+	// A gateway countersigns the request: the Authorization header of the sender is
+	// forwarded unchanged and the gateway signature is added on top of it.
 	header := model.AuthHeaderSubscriber
 	if ctx.Role == model.RoleGateway {
 		header = model.AuthHeaderGateway
@@ -55,6 +64,23 @@ func (s *signStep) Run(ctx *model.StepContext) error {
 	return nil
 }
 
+// sign signs body with the keyset of subID and returns the Authorization header value.
+func (s *signStep) sign(ctx context.Context, subID string, body []byte) (string, error) {
+	keySet, err := s.km.Keyset(ctx, subID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get signing key: %w", err)
+	}
+	now := time.Now()
+	createdAt := now.Unix()
+	validTill := now.Add(s.ttl).Unix()
+	sign, err := s.signer.Sign(ctx, body, keySet.SigningPrivate, createdAt, validTill)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign request: %w", err)
+	}
+	log.Debugf(ctx, "Signature generated: %v", sign)
+	return s.generateAuthHeader(subID, keySet.UniqueKeyID, createdAt, validTill, sign), nil
+}
+
 // generateAuthHeader constructs the authorization header for the signed request.
 // It includes key ID, algorithm, creation time, expiration time, required headers, and signature.
 func (s *signStep) generateAuthHeader(subID, keyID string, createdAt, validTill int64, signature string) string {
@@ -64,21 +90,31 @@ func (s *signStep) generateAuthHeader(subID, keyID string, createdAt, validTill
 	)
 }
 
+// replayKeyPrefix namespaces the replay detection entries in the cache.
+const replayKeyPrefix = "replay:"
+
 // validateSignStep represents the signature validation step.
 type validateSignStep struct {
 	validator definition.SignValidator
 	km        definition.KeyManager
+	cache     definition.Cache // cache records accepted signatures for replay detection; nil disables it.
+	skew      time.Duration    // skew is the clock skew tolerated by the validator after a signature expires.
 }
 
 // newValidateSignStep initializes and returns a new validate sign step.
-func newValidateSignStep(signValidator definition.SignValidator, km definition.KeyManager) (definition.Step, error) {
+// If cache is not nil, requests that were already accepted are rejected as replays.
+func newValidateSignStep(signValidator definition.SignValidator, km definition.KeyManager, cache definition.Cache) (*validateSignStep, error) {
 	if signValidator == nil {
 		return nil, fmt.Errorf("invalid config: SignValidator plugin not configured")
 	}
 	if km == nil {
 		return nil, fmt.Errorf("invalid config: KeyManager plugin not configured")
 	}
-	return &validateSignStep{validator: signValidator, km: km}, nil
+	s := &validateSignStep{validator: signValidator, km: km, cache: cache}
+	if st, ok := signValidator.(definition.SkewTolerator); ok {
+		s.skew = st.AllowedSkew()
+	}
+	return s, nil
 }
 
 // Run executes the validation step.
@@ -87,7 +123,7 @@ func (s *validateSignStep) Run(ctx *model.StepContext) error {
 	headerValue := ctx.Request.Header.Get(model.AuthHeaderGateway)
 	if len(headerValue) != 0 {
 		log.Debugf(ctx, "Validating %v Header", model.AuthHeaderGateway)
-		if err := s.validate(ctx, headerValue); err != nil {
+		if err := s.validate(ctx, ctx.Body, headerValue); err != nil {
 			ctx.RespHeader.Set(model.UnaAuthorizedHeaderGateway, unauthHeader)
 			return model.NewSignValidationErr(fmt.Errorf("failed to validate %s: %w", model.AuthHeaderGateway, err))
 		}
@@ -99,15 +135,67 @@ func (s *validateSignStep) Run(ctx *model.StepContext) error {
 		ctx.RespHeader.Set(model.UnaAuthorizedHeaderSubscriber, unauthHeader)
 		return model.NewSignValidationErr(fmt.Errorf("%s missing", model.UnaAuthorizedHeaderSubscriber))
 	}
-	if err := s.validate(ctx, headerValue); err != nil {
+	if err := s.validate(ctx, ctx.Body, headerValue); err != nil {
 		ctx.RespHeader.Set(model.UnaAuthorizedHeaderSubscriber, unauthHeader)
 		return model.NewSignValidationErr(fmt.Errorf("failed to validate %s: %w", model.AuthHeaderSubscriber, err))
 	}
+	if s.cache != nil {
+		if err := s.checkReplay(ctx, headerValue); err != nil {
+			return err
+		}
+	}
+	subscriberID, _, _ := strings.Cut(headerParam(headerValue, "keyId"), "|")
+	withStepValue(ctx, signValidatedKey{}, subscriberID)
+	return nil
+}
+
+// signValidatedKey is the context key under which the validateSign step records the
+// subscriber ID in the keyId of the subscriber signature that it validated.
+type signValidatedKey struct{}
+
+// signValidated reports whether the validateSign step validated the signature of the request.
+func signValidated(ctx context.Context) bool {
+	_, ok := ctx.Value(signValidatedKey{}).(string)
+	return ok
+}
+
+// signer returns the subscriber ID of the validated subscriber signature of the
+// request, or an empty string if the validateSign step did not validate it.
+func signer(ctx context.Context) string {
+	subscriberID, _ := ctx.Value(signValidatedKey{}).(string)
+	return subscriberID
+}
+
+// checkReplay rejects a request whose signature and message_id were already accepted.
+// Accepted requests are recorded in the cache until their signature expires, plus the
+// clock skew the validator tolerates, after which the validator rejects them anyway.
+func (s *validateSignStep) checkReplay(ctx *model.StepContext, header string) error {
+	expires, err := strconv.ParseInt(headerParam(header, "expires"), 10, 64)
+	if err != nil {
+		return model.NewSignValidationErr(fmt.Errorf("invalid expires timestamp: %w", err))
+	}
+	ttl := time.Until(time.Unix(expires, 0).Add(s.skew))
+	if ttl <= 0 {
+		return model.NewSignValidationErr(fmt.Errorf("signature is expired"))
+	}
+	_, msgID := messageIDs(ctx, ctx.Body)
+	sum := sha256.Sum256([]byte(headerParam(header, "signature") + "|" + msgID))
+	key := replayKeyPrefix + hex.EncodeToString(sum[:])
+
+	// The lookup and the write are not atomic, so two copies of a request arriving
+	// at the same moment may both be accepted.
+	if _, err := s.cache.Get(ctx, key); err == nil {
+		log.Warnf(ctx, "Replayed request rejected, message_id: %s", msgID)
+		return model.NewReplayErr(fmt.Errorf("request with message_id %s and the same signature was already received", msgID))
+	}
+	if err := s.cache.Set(ctx, key, msgID, ttl); err != nil {
+		return fmt.Errorf("failed to record request for replay detection: %w", err)
+	}
 	return nil
 }
 
 // validate checks the validity of the provided signature header.
-func (s *validateSignStep) validate(ctx *model.StepContext, value string) error {
+func (s *validateSignStep) validate(ctx context.Context, body []byte, value string) error {
 	headerVals, err := parseHeader(value)
 	if err != nil {
 		return fmt.Errorf("failed to parse header")
@@ -117,7 +205,7 @@ func (s *validateSignStep) validate(ctx *model.StepContext, value string) error
 	if err != nil {
 		return fmt.Errorf("failed to get validation key: %w", err)
 	}
-	if err := s.validator.Validate(ctx, ctx.Body, value, signingPublicKey); err != nil {
+	if err := s.validator.Validate(ctx, body, value, signingPublicKey); err != nil {
 		return fmt.Errorf("sign validation failed: %w", err)
 	}
 	return nil
@@ -134,17 +222,7 @@ type authHeader struct {
 // Example keyId format: "{subscriber_id}|{unique_key_id}|{algorithm}"
 func parseHeader(header string) (*authHeader, error) {
 	// Example: Signature keyId="bpp.example.com|key-1|ed25519",algorithm="ed25519",...
-	keyIDPart := ""
-	// Look for keyId="<value>"
-	const keyIdPrefix = `keyId="`
-	startIndex := strings.Index(header, keyIdPrefix)
-	if startIndex != -1 {
-		startIndex += len(keyIdPrefix)
-		endIndex := strings.Index(header[startIndex:], `"`)
-		if endIndex != -1 {
-			keyIDPart = strings.TrimSpace(header[startIndex : startIndex+endIndex])
-		}
-	}
+	keyIDPart := headerParam(header, "keyId")
 
 	if keyIDPart == "" {
 		return nil, fmt.Errorf("keyId parameter not found in Authorization header")
@@ -162,6 +240,21 @@ func parseHeader(header string) (*authHeader, error) {
 	}, nil
 }
 
+// headerParam returns the value of the quoted parameter name="<value>" in a Signature header.
+func headerParam(header, name string) string {
+	prefix := name + `="`
+	startIndex := strings.Index(header, prefix)
+	if startIndex == -1 {
+		return ""
+	}
+	startIndex += len(prefix)
+	endIndex := strings.Index(header[startIndex:], `"`)
+	if endIndex == -1 {
+		return ""
+	}
+	return strings.TrimSpace(header[startIndex : startIndex+endIndex])
+}
+
 // validateSchemaStep represents the schema validation step.
 type validateSchemaStep struct {
 	validator definition.SchemaValidator
@@ -186,15 +279,16 @@ func (s *validateSchemaStep) Run(ctx *model.StepContext) error {
 
 // addRouteStep represents the route determination step.
 type addRouteStep struct {
-	router definition.Router
+	router   definition.Router
+	registry definition.RegistryLookup // registry resolves the destinations of broadcast lookups.
 }
 
 // newAddRouteStep creates and returns the addRoute step after validation.
-func newAddRouteStep(router definition.Router) (definition.Step, error) {
+func newAddRouteStep(router definition.Router, registry definition.RegistryLookup) (definition.Step, error) {
 	if router == nil {
 		return nil, fmt.Errorf("invalid config: Router plugin not configured")
 	}
-	return &addRouteStep{router: router}, nil
+	return &addRouteStep{router: router, registry: registry}, nil
 }
 
 // Run executes the routing step.
@@ -207,6 +301,75 @@ func (s *addRouteStep) Run(ctx *model.StepContext) error {
 		TargetType:  route.TargetType,
 		PublisherID: route.PublisherID,
 		URL:         route.URL,
+		URLs:        route.URLs,
+	}
+	if route.Lookup != nil {
+		if ctx.Route.URLs, err = s.lookup(ctx, route.Lookup); err != nil {
+			return err
+		}
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// lookup resolves the destinations of a broadcast route to the action endpoints of
+// the registry subscribers that match the filter.
+func (s *addRouteStep) lookup(ctx *model.StepContext, filter *model.Subscriber) ([]*url.URL, error) {
+	if s.registry == nil {
+		return nil, fmt.Errorf("invalid config: Registry plugin not configured for broadcast lookup")
+	}
+	subs, err := s.registry.Lookup(ctx, &model.Subscription{Subscriber: *filter})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up broadcast destinations: %w", err)
+	}
+	targets := subscriberURLs(ctx, subs, path.Base(ctx.Request.URL.Path))
+	if len(targets) == 0 {
+		return nil, model.NewNotFoundErr(fmt.Errorf("no %s subscribers found for domain %s", filter.Type, filter.Domain))
+	}
+	return targets, nil
+}
+
+// instrumentedStep wraps a step to record its duration and failures under the step name.
+type instrumentedStep struct {
+	name string
+	step definition.Step
+}
+
+// Run executes the wrapped step in its own span and records its metrics. The parent
+// context is restored afterwards, with the values that the step added with
+// withStepValue, so that the span of the step is not the parent of later spans.
+func (s *instrumentedStep) Run(ctx *model.StepContext) error {
+	parent := ctx.Context
+	var values []stepValue
+	spanCtx, span := tracing.Start(context.WithValue(parent, stepValuesKey{}, &values), "step "+s.name)
+	ctx.Context = spanCtx
+
+	start := time.Now()
+	err := s.step.Run(ctx)
+	metrics.ObserveStep(ctx, s.name, time.Since(start), err)
+
+	ctx.Context = parent
+	for _, v := range values {
+		withStepValue(ctx, v.key, v.val)
+	}
+	tracing.End(span, err)
+	return err
+}
+
+// stepValuesKey is the context key under which instrumentedStep collects the values
+// that its step adds to the request context.
+type stepValuesKey struct{}
+
+// stepValue is a value added to the request context by a step.
+type stepValue struct {
+	key, val any
+}
+
+// withStepValue adds val under key to the context of the request, for the later
+// steps and the handler. Unlike a value added to ctx.Context directly, it is kept
+// when the instrumentedStep running the step restores the parent context.
+func withStepValue(ctx *model.StepContext, key, val any) {
+	if values, ok := ctx.Value(stepValuesKey{}).(*[]stepValue); ok {
+		*values = append(*values, stepValue{key: key, val: val})
+	}
+	ctx.Context = context.WithValue(ctx.Context, key, val)
+}
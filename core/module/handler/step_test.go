@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// mockSignValidator accepts every signature.
+type mockSignValidator struct{}
+
+func (m *mockSignValidator) Validate(ctx context.Context, body []byte, header string, publicKeyBase64 string) error {
+	return nil
+}
+
+// mockKeyManager returns a fixed public key for every subscriber.
+type mockKeyManager struct {
+	definition.KeyManager
+}
+
+func (m *mockKeyManager) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID string) (string, string, error) {
+	return "signing-public-key", "encr-public-key", nil
+}
+
+// mockCache is an in-memory definition.Cache that records the TTL of each entry.
+type mockCache struct {
+	mu   sync.Mutex
+	data map[string]string
+	ttls map[string]time.Duration
+}
+
+func newMockCache() *mockCache {
+	return &mockCache{data: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (m *mockCache) Get(ctx context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.data[key]
+	if !ok {
+		return "", definition.ErrCacheMiss
+	}
+	return v, nil
+}
+
+func (m *mockCache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = value
+	m.ttls[key] = ttl
+	return nil
+}
+
+func (m *mockCache) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+func (m *mockCache) Clear(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data = map[string]string{}
+	return nil
+}
+
+// mockSigner records the validity period of the last signature it generated.
+type mockSigner struct {
+	ttl     time.Duration
+	created int64
+	expires int64
+}
+
+func (m *mockSigner) Sign(ctx context.Context, body []byte, privateKeyBase64 string, createdAt, expiresAt int64) (string, error) {
+	m.created, m.expires = createdAt, expiresAt
+	return "signature", nil
+}
+
+func (m *mockSigner) TTL() time.Duration {
+	return m.ttl
+}
+
+// signKeyManager returns a fixed keyset for signing.
+type signKeyManager struct {
+	definition.KeyManager
+}
+
+func (m *signKeyManager) Keyset(ctx context.Context, keyID string) (*model.Keyset, error) {
+	return &model.Keyset{UniqueKeyID: "key-1", SigningPrivate: "private"}, nil
+}
+
+// TestSignStepTTL tests that the sign step uses the validity period configured on the signer.
+func TestSignStepTTL(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		want int64
+	}{
+		{name: "default", want: int64(defaultSignatureTTL.Seconds())},
+		{name: "configured", ttl: 30 * time.Second, want: 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := &mockSigner{ttl: tt.ttl}
+			step, err := newSignStep(signer, &signKeyManager{})
+			if err != nil {
+				t.Fatalf("newSignStep() error = %v", err)
+			}
+			ctx := &model.StepContext{
+				Context: context.Background(),
+				Request: httptest.NewRequest(http.MethodPost, "/bap/caller/search", nil),
+				SubID:   "bap.example.com",
+			}
+			if err := step.Run(ctx); err != nil {
+				t.Fatalf("Run() error = %v", err)
+			}
+			if got := signer.expires - signer.created; got != tt.want {
+				t.Errorf("signature validity = %ds, want %ds", got, tt.want)
+			}
+			if !strings.Contains(ctx.Request.Header.Get(model.AuthHeaderSubscriber), fmt.Sprintf(`expires="%d"`, signer.expires)) {
+				t.Errorf("Authorization header = %q, want expires=%d", ctx.Request.Header.Get(model.AuthHeaderSubscriber), signer.expires)
+			}
+		})
+	}
+}
+
+// TestSignStepGateway tests that a gateway adds its signature without replacing the sender's.
+func TestSignStepGateway(t *testing.T) {
+	step, err := newSignStep(&mockSigner{}, &signKeyManager{})
+	if err != nil {
+		t.Fatalf("newSignStep() error = %v", err)
+	}
+	ctx := &model.StepContext{
+		Context: context.Background(),
+		Request: httptest.NewRequest(http.MethodPost, "/bg/receiver/search", nil),
+		SubID:   "bg.example.com",
+		Role:    model.RoleGateway,
+	}
+	ctx.Request.Header.Set(model.AuthHeaderSubscriber, "bap-signature")
+	if err := step.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if got := ctx.Request.Header.Get(model.AuthHeaderSubscriber); got != "bap-signature" {
+		t.Errorf("%s = %q, want the sender's signature", model.AuthHeaderSubscriber, got)
+	}
+	if got := ctx.Request.Header.Get(model.AuthHeaderGateway); !strings.Contains(got, `keyId="bg.example.com|key-1|ed25519"`) {
+		t.Errorf("%s = %q, want the gateway signature", model.AuthHeaderGateway, got)
+	}
+}
+
+// signedStepCtx returns a step context for a request with the given signature and message_id.
+func signedStepCtx(signature, msgID string, expires time.Time) *model.StepContext {
+	body := fmt.Sprintf(`{"context":{"action":"search","message_id":%q}}`, msgID)
+	req := httptest.NewRequest(http.MethodPost, "/bpp/receiver/search", nil)
+	req.Header.Set(model.AuthHeaderSubscriber, fmt.Sprintf(
+		`Signature keyId="bap.example.com|key-1|ed25519",algorithm="ed25519",created="%d",expires="%d",headers="(created) (expires) digest",signature="%s"`,
+		time.Now().Unix(), expires.Unix(), signature))
+	return &model.StepContext{
+		Context:    context.Background(),
+		Request:    req,
+		Body:       []byte(body),
+		RespHeader: http.Header{},
+	}
+}
+
+// TestValidateSignReplay tests that a request is rejected when its signature and message_id were already accepted.
+func TestValidateSignReplay(t *testing.T) {
+	cache := newMockCache()
+	step, err := newValidateSignStep(&mockSignValidator{}, &mockKeyManager{}, cache)
+	if err != nil {
+		t.Fatalf("newValidateSignStep() error = %v", err)
+	}
+	expires := time.Now().Add(5 * time.Minute)
+
+	first := signedStepCtx("sig-1", "msg-1", expires)
+	if err := step.Run(first); err != nil {
+		t.Fatalf("Run() error = %v for the first request, want nil", err)
+	}
+	if !signValidated(first) {
+		t.Error("first request was not marked as validated")
+	}
+	replayed := signedStepCtx("sig-1", "msg-1", expires)
+	err = step.Run(replayed)
+	var replayErr *model.ReplayErr
+	if !errors.As(err, &replayErr) {
+		t.Fatalf("Run() error = %v for a replayed request, want ReplayErr", err)
+	}
+	if signValidated(replayed) {
+		t.Error("replayed request was marked as validated")
+	}
+
+	// A new signature or a new message_id is not a replay.
+	if err := step.Run(signedStepCtx("sig-2", "msg-1", expires)); err != nil {
+		t.Errorf("Run() error = %v for a new signature, want nil", err)
+	}
+	if err := step.Run(signedStepCtx("sig-1", "msg-2", expires)); err != nil {
+		t.Errorf("Run() error = %v for a new message_id, want nil", err)
+	}
+
+	for key, ttl := range cache.ttls {
+		if ttl <= 0 || ttl > 5*time.Minute {
+			t.Errorf("ttl of %s = %v, want the remaining signature validity", key, ttl)
+		}
+	}
+}
+
+// skewSignValidator accepts every signature and tolerates a clock skew.
+type skewSignValidator struct {
+	mockSignValidator
+	skew time.Duration
+}
+
+func (m *skewSignValidator) AllowedSkew() time.Duration {
+	return m.skew
+}
+
+// TestValidateSignReplaySkew tests that the clock skew of the validator extends the
+// replay window beyond the expiry of the signature.
+func TestValidateSignReplaySkew(t *testing.T) {
+	cache := newMockCache()
+	step, err := newValidateSignStep(&skewSignValidator{skew: time.Minute}, &mockKeyManager{}, cache)
+	if err != nil {
+		t.Fatalf("newValidateSignStep() error = %v", err)
+	}
+	expires := time.Now().Add(-10 * time.Second)
+
+	if err := step.Run(signedStepCtx("sig-1", "msg-1", expires)); err != nil {
+		t.Fatalf("Run() error = %v for a signature expired within the skew, want nil", err)
+	}
+	err = step.Run(signedStepCtx("sig-1", "msg-1", expires))
+	var replayErr *model.ReplayErr
+	if !errors.As(err, &replayErr) {
+		t.Fatalf("Run() error = %v for a replayed request, want ReplayErr", err)
+	}
+	for key, ttl := range cache.ttls {
+		if ttl < 40*time.Second || ttl > 50*time.Second {
+			t.Errorf("ttl of %s = %v, want the remaining validity including the skew", key, ttl)
+		}
+	}
+
+	expired := signedStepCtx("sig-2", "msg-1", time.Now().Add(-2*time.Minute))
+	if err := step.Run(expired); err == nil {
+		t.Error("Run() error = nil for a signature expired beyond the skew, want error")
+	}
+}
+
+// TestValidateSignReplayDisabled tests that duplicates are accepted when replay protection is off.
+func TestValidateSignReplayDisabled(t *testing.T) {
+	step, err := newValidateSignStep(&mockSignValidator{}, &mockKeyManager{}, nil)
+	if err != nil {
+		t.Fatalf("newValidateSignStep() error = %v", err)
+	}
+	expires := time.Now().Add(5 * time.Minute)
+	for i := 0; i < 2; i++ {
+		if err := step.Run(signedStepCtx("sig-1", "msg-1", expires)); err != nil {
+			t.Fatalf("Run() error = %v, want nil", err)
+		}
+	}
+}
+
+// TestHeaderParam tests extraction of quoted parameters from a Signature header.
+func TestHeaderParam(t *testing.T) {
+	header := `Signature keyId="bap.example.com|key-1|ed25519",algorithm="ed25519",created="1",expires="2",headers="(created) (expires) digest",signature="abc="`
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "keyId", want: "bap.example.com|key-1|ed25519"},
+		{name: "expires", want: "2"},
+		{name: "signature", want: "abc="},
+		{name: "missing", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := headerParam(header, tt.name); got != tt.want {
+				t.Errorf("headerParam(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+// mockRouter returns a fixed route.
+type mockRouter struct {
+	route *model.Route
+}
+
+func (m *mockRouter) Route(ctx context.Context, url *url.URL, body []byte) (*model.Route, error) {
+	return m.route, nil
+}
+
+// TestAddRouteBroadcastLookup tests that broadcast destinations are looked up in the registry.
+func TestAddRouteBroadcastLookup(t *testing.T) {
+	filter := &model.Subscriber{Type: "BPP", Domain: "ONDC:RET10"}
+	router := &mockRouter{route: &model.Route{TargetType: "broadcast", Lookup: filter}}
+	registry := &mockRegistry{subs: []model.Subscription{
+		{Subscriber: model.Subscriber{SubscriberID: "bpp1", URL: "https://bpp1.example.com"}},
+		{Subscriber: model.Subscriber{SubscriberID: "bpp2", URL: "https://bpp2.example.com/beckn"}},
+	}}
+	step, err := newAddRouteStep(router, registry)
+	if err != nil {
+		t.Fatalf("newAddRouteStep() error = %v", err)
+	}
+	ctx := &model.StepContext{
+		Context: context.Background(),
+		Request: httptest.NewRequest(http.MethodPost, "/bap/caller/search", nil),
+	}
+	if err := step.Run(ctx); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if registry.req.Subscriber != *filter {
+		t.Errorf("Lookup() filter = %+v, want %+v", registry.req.Subscriber, *filter)
+	}
+	var got []string
+	for _, u := range ctx.Route.URLs {
+		got = append(got, u.String())
+	}
+	if want := "https://bpp1.example.com/search,https://bpp2.example.com/beckn/search"; strings.Join(got, ",") != want {
+		t.Errorf("Route.URLs = %v, want %s", got, want)
+	}
+
+	// Without subscribers there is nowhere to broadcast to.
+	registry.subs = nil
+	var notFound *model.NotFoundErr
+	if err := step.Run(ctx); !errors.As(err, &notFound) {
+		t.Errorf("Run() error = %v without subscribers, want NotFoundErr", err)
+	}
+}
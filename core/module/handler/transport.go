@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+)
+
+// Default values for the retry policy and circuit breaker, used when the config leaves them unset.
+var (
+	defaultRetryBackoff     = 100 * time.Millisecond
+	defaultRetryStatusCodes = []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+// breakerIdleTimeout is how long the circuit of a host is kept after its last
+// failure. Hosts without recent failures have no circuit, so that the breaker does
+// not grow with every host ever contacted.
+const breakerIdleTimeout = 10 * time.Minute
+
+// errCircuitOpen is returned when a request is rejected because the circuit for its host is open.
+var errCircuitOpen = errors.New("circuit open")
+
+// breakerState is the state of the circuit for a single host.
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// hostBreaker tracks consecutive failures for a single host.
+type hostBreaker struct {
+	state    breakerState
+	failures int
+	openedAt time.Time
+	failedAt time.Time // failedAt is the time of the last failure.
+}
+
+// circuitBreaker keeps a circuit per host. A circuit opens after a run of consecutive
+// failures, rejects requests while open, and lets a single probe through once the
+// open timeout has passed. The probe's outcome closes or re-opens the circuit.
+type circuitBreaker struct {
+	threshold   int
+	openTimeout time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	hosts     map[string]*hostBreaker
+	lastSweep time.Time
+}
+
+// newCircuitBreaker returns a circuit breaker for the given config, or nil if it is disabled.
+func newCircuitBreaker(cfg *CircuitBreakerConfig) *circuitBreaker {
+	if cfg.FailureThreshold <= 0 {
+		return nil
+	}
+	openTimeout := cfg.OpenTimeout
+	if openTimeout <= 0 {
+		openTimeout = defaultOpenTimeout
+	}
+	return &circuitBreaker{
+		threshold:   cfg.FailureThreshold,
+		openTimeout: openTimeout,
+		now:         time.Now,
+		hosts:       map[string]*hostBreaker{},
+	}
+}
+
+// allow reports whether a request to host may be sent.
+func (cb *circuitBreaker) allow(host string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	hb, ok := cb.hosts[host]
+	if !ok {
+		return true
+	}
+	switch hb.state {
+	case breakerOpen:
+		if cb.now().Sub(hb.openedAt) < cb.openTimeout {
+			return false
+		}
+		// Let this request through as the probe; others keep failing fast until it completes.
+		hb.state = breakerHalfOpen
+		return true
+	case breakerHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// record updates the circuit for host with the outcome of a request. A success
+// closes the circuit, which is the same as having none, so it is removed.
+func (cb *circuitBreaker) record(ctx context.Context, host string, success bool) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	now := cb.now()
+	cb.sweep(now)
+	hb, ok := cb.hosts[host]
+	if success {
+		if ok && hb.state != breakerClosed {
+			log.Infof(ctx, "Circuit closed for host %s", host)
+		}
+		delete(cb.hosts, host)
+		return
+	}
+	if !ok {
+		hb = &hostBreaker{}
+		cb.hosts[host] = hb
+	}
+	hb.failedAt = now
+	hb.failures++
+	if hb.state == breakerHalfOpen || hb.failures >= cb.threshold {
+		if hb.state != breakerOpen {
+			log.Warnf(ctx, "Circuit opened for host %s after %d consecutive failures", host, hb.failures)
+		}
+		hb.state = breakerOpen
+		hb.openedAt = now
+	}
+}
+
+// sweep removes the circuits of hosts that have not failed for breakerIdleTimeout,
+// except those with a probe in flight. It runs at most once per breakerIdleTimeout.
+func (cb *circuitBreaker) sweep(now time.Time) {
+	if now.Sub(cb.lastSweep) < breakerIdleTimeout {
+		return
+	}
+	cb.lastSweep = now
+	for host, hb := range cb.hosts {
+		if hb.state != breakerHalfOpen && now.Sub(hb.failedAt) >= breakerIdleTimeout {
+			delete(cb.hosts, host)
+		}
+	}
+}
+
+// noRetryKey marks the context of requests that retryTransport must send only once.
+type noRetryKey struct{}
+
+// withoutRetries returns a context whose requests are not retried by retryTransport,
+// for callers that retry deliveries themselves. The circuit breaker still applies.
+func withoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetryKey{}, true)
+}
+
+// retryTransport is an http.RoundTripper that retries failed requests and guards
+// each host with a circuit breaker.
+type retryTransport struct {
+	base        http.RoundTripper
+	maxAttempts int
+	backoff     time.Duration
+	statusCodes []int
+	breaker     *circuitBreaker
+}
+
+// newTransport wraps base with the retry policy and circuit breaker from cfg.
+// base is returned unchanged if neither is configured.
+func newTransport(base http.RoundTripper, cfg *HttpClientConfig) http.RoundTripper {
+	breaker := newCircuitBreaker(&cfg.CircuitBreaker)
+	if cfg.Retry.MaxAttempts <= 1 && breaker == nil {
+		return base
+	}
+	t := &retryTransport{
+		base:        base,
+		maxAttempts: max(cfg.Retry.MaxAttempts, 1),
+		backoff:     cfg.Retry.Backoff,
+		statusCodes: cfg.Retry.StatusCodes,
+		breaker:     breaker,
+	}
+	if t.backoff <= 0 {
+		t.backoff = defaultRetryBackoff
+	}
+	if len(t.statusCodes) == 0 {
+		t.statusCodes = defaultRetryStatusCodes
+	}
+	return t
+}
+
+// RoundTrip sends the request, retrying retryable status codes and the transport
+// errors that occurred before the request was written, with exponential backoff.
+// An error after that may come after the upstream received the request, so it is
+// not retried. Requests whose body cannot be replayed, and requests whose context
+// was marked by withoutRetries, are sent once.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	attempts := t.maxAttempts
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		attempts = 1
+	}
+	if req.Context().Value(noRetryKey{}) != nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if t.breaker != nil && !t.breaker.allow(host) {
+			return nil, fmt.Errorf("%w for host %s", errCircuitOpen, host)
+		}
+		r := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to reset request body: %w", err)
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		var wrote atomic.Bool
+		r = r.WithContext(httptrace.WithClientTrace(r.Context(), &httptrace.ClientTrace{
+			WroteHeaders: func() { wrote.Store(true) },
+		}))
+		resp, err := t.base.RoundTrip(r)
+		if t.breaker != nil {
+			t.breaker.record(req.Context(), host, err == nil && resp.StatusCode < http.StatusInternalServerError)
+		}
+		var retry bool
+		if err != nil {
+			retry = !wrote.Load()
+		} else {
+			retry = slices.Contains(t.statusCodes, resp.StatusCode)
+		}
+		if !retry || attempt >= attempts {
+			return resp, err
+		}
+
+		if err != nil {
+			log.Warnf(req.Context(), "Attempt %d to %s failed, retrying: %v", attempt, host, err)
+		} else {
+			log.Warnf(req.Context(), "Attempt %d to %s returned status %d, retrying", attempt, host, resp.StatusCode)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(t.backoff << (attempt - 1)):
+		}
+	}
+}
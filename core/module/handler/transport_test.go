@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// statusSequenceServer returns a server that replies with the given status codes in order,
+// repeating the last one, and records the bodies it receives.
+func statusSequenceServer(t *testing.T, codes ...int) (*httptest.Server, *atomic.Int32, chan string) {
+	t.Helper()
+	var calls atomic.Int32
+	bodies := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1))
+		body, _ := io.ReadAll(r.Body)
+		bodies <- string(body)
+		w.WriteHeader(codes[min(n, len(codes))-1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, bodies
+}
+
+// TestNewTransportDisabled tests that the base transport is used when nothing is configured.
+func TestNewTransportDisabled(t *testing.T) {
+	base := http.DefaultTransport
+	if got := newTransport(base, &HttpClientConfig{}); got != base {
+		t.Errorf("newTransport() = %T, want base transport", got)
+	}
+	if got := newTransport(base, &HttpClientConfig{Retry: RetryConfig{MaxAttempts: 2}}); got == base {
+		t.Error("newTransport() returned base transport with retries configured")
+	}
+}
+
+// TestRetryTransport tests that retryable failures are retried with the body replayed.
+func TestRetryTransport(t *testing.T) {
+	tests := []struct {
+		name        string
+		codes       []int
+		statusCodes []int
+		wantCalls   int32
+		wantStatus  int
+	}{
+		{
+			name:       "retries until success",
+			codes:      []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			wantCalls:  3,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "gives up after max attempts",
+			codes:      []int{http.StatusServiceUnavailable},
+			wantCalls:  3,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "status not retried by default",
+			codes:      []int{http.StatusInternalServerError},
+			wantCalls:  1,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:        "configured status codes",
+			codes:       []int{http.StatusInternalServerError, http.StatusOK},
+			statusCodes: []int{http.StatusInternalServerError},
+			wantCalls:   2,
+			wantStatus:  http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls, bodies := statusSequenceServer(t, tt.codes...)
+			client := newHTTPClient(&HttpClientConfig{
+				Retry: RetryConfig{MaxAttempts: 3, Backoff: time.Millisecond, StatusCodes: tt.statusCodes},
+			})
+
+			resp, err := client.Post(srv.URL, "application/json", bytes.NewBufferString(`{"a":1}`))
+			if err != nil {
+				t.Fatalf("Post() error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			close(bodies)
+			for body := range bodies {
+				if body != `{"a":1}` {
+					t.Errorf("body = %q, want %q", body, `{"a":1}`)
+				}
+			}
+		})
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip calls f.
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// TestRetryTransportErrors tests that only errors before the request was written are retried.
+func TestRetryTransportErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		written   bool
+		wantCalls int32
+	}{
+		{name: "error before sending", wantCalls: 3},
+		{name: "error after sending", written: true, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			base := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+				calls.Add(1)
+				if tt.written {
+					httptrace.ContextClientTrace(r.Context()).WroteHeaders()
+				}
+				return nil, errors.New("connection reset")
+			})
+			client := &http.Client{Transport: newTransport(base, &HttpClientConfig{
+				Retry: RetryConfig{MaxAttempts: 3, Backoff: time.Millisecond},
+			})}
+
+			if _, err := client.Post("http://bpp.example.com", "application/json", bytes.NewBufferString(`{"a":1}`)); err == nil {
+				t.Fatal("Post() error = nil, want error")
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+// TestCircuitBreakerEviction tests that circuits are dropped once their hosts recover
+// or stop failing.
+func TestCircuitBreakerEviction(t *testing.T) {
+	now := time.Now()
+	cb := newCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	cb.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	cb.record(ctx, "recovered", false)
+	cb.record(ctx, "recovered", true)
+	cb.record(ctx, "idle", false)
+	cb.record(ctx, "idle", false)
+	if len(cb.hosts) != 1 {
+		t.Fatalf("hosts = %d, want 1", len(cb.hosts))
+	}
+
+	now = now.Add(breakerIdleTimeout)
+	cb.record(ctx, "bpp", false)
+	if _, ok := cb.hosts["idle"]; ok {
+		t.Error("circuit of an idle host was not evicted")
+	}
+	if _, ok := cb.hosts["bpp"]; !ok {
+		t.Error("circuit of a failing host was evicted")
+	}
+}
+
+// TestCircuitBreaker tests the open, half-open and closed transitions of a host circuit.
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Now()
+	cb := newCircuitBreaker(&CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute})
+	cb.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	cb.record(ctx, "bpp", false)
+	if !cb.allow("bpp") {
+		t.Fatal("allow() = false before reaching the failure threshold")
+	}
+	cb.record(ctx, "bpp", false)
+	if cb.allow("bpp") {
+		t.Fatal("allow() = true after reaching the failure threshold")
+	}
+	if !cb.allow("other") {
+		t.Fatal("allow() = false for a different host")
+	}
+
+	now = now.Add(time.Minute)
+	if !cb.allow("bpp") {
+		t.Fatal("allow() = false for the probe after the open timeout")
+	}
+	if cb.allow("bpp") {
+		t.Fatal("allow() = true while the probe is in flight")
+	}
+	cb.record(ctx, "bpp", false)
+	if cb.allow("bpp") {
+		t.Fatal("allow() = true after a failed probe")
+	}
+
+	now = now.Add(time.Minute)
+	if !cb.allow("bpp") {
+		t.Fatal("allow() = false for the second probe")
+	}
+	cb.record(ctx, "bpp", true)
+	if !cb.allow("bpp") || !cb.allow("bpp") {
+		t.Fatal("allow() = false after a successful probe")
+	}
+}
+
+// TestProxyCircuitOpen tests that the proxy NACKs without contacting an unhealthy host.
+func TestProxyCircuitOpen(t *testing.T) {
+	srv, calls, _ := statusSequenceServer(t, http.StatusServiceUnavailable)
+	target, _ := url.Parse(srv.URL)
+	client := newHTTPClient(&HttpClientConfig{
+		CircuitBreaker: CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute},
+	})
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", bytes.NewBufferString(asyncTestBody))
+		ctx := &model.StepContext{
+			Context: req.Context(),
+			Request: req,
+			Body:    []byte(asyncTestBody),
+			Route:   &model.Route{TargetType: "url", URL: target},
+		}
+		rec := httptest.NewRecorder()
+		proxy(ctx, req, rec, client, nil)
+		return rec
+	}
+
+	if rec := send(); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first request status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	rec := send()
+	if rec.Code != http.StatusServiceUnavailable || ackStatus(t, rec) != model.StatusNACK {
+		t.Fatalf("second request = %d %s, want 503 NACK", rec.Code, rec.Body.String())
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+
+	// The error returned by the transport identifies the open circuit.
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	if _, err := client.Transport.RoundTrip(req); !errors.Is(err, errCircuitOpen) {
+		t.Errorf("RoundTrip() error = %v, want %v", err, errCircuitOpen)
+	}
+}
@@ -1,13 +1,26 @@
 package module
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/beckn-one/beckn-onix/core/module/handler"
 	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
 	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/response"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 )
 
 // Config represents the configuration for a module.
@@ -15,6 +28,10 @@ type Config struct {
 	Name    string `yaml:"name"`
 	Path    string `yaml:"path"`
 	Handler handler.Config
+
+	// MaxBodyBytes is the largest request body the module accepts. Larger requests
+	// are NACKed with 413. Zero means no limit.
+	MaxBodyBytes int64 `yaml:"maxBodyBytes"`
 }
 
 // Provider represents a function that initializes an HTTP handler using a PluginManager.
@@ -27,31 +44,55 @@ var handlerProviders = map[handler.Type]Provider{
 
 // Register initializes and registers handlers based on the provided configuration.
 // It iterates over the module configurations, retrieves appropriate handler providers,
-// and registers the handlers with the HTTP multiplexer.
-func Register(ctx context.Context, mCfgs []Config, mux *http.ServeMux, mgr handler.PluginManager) error {
+// and registers the handlers with the HTTP multiplexer. The returned closer releases
+// the resources of the handlers; it must be called once they no longer serve requests.
+func Register(ctx context.Context, mCfgs []Config, mux *http.ServeMux, mgr handler.PluginManager) (_ func(), err error) {
+	var closers []handler.Closer
+	closeAll := func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}
+	defer func() {
+		if err != nil {
+			closeAll()
+		}
+	}()
 	mux.Handle("/health", http.HandlerFunc(handler.HealthHandler))
+	mux.Handle("/metrics", metrics.Handler())
+	checkers := map[string]handler.DependencyChecker{}
 
 	log.Debugf(ctx, "Registering modules with config: %#v", mCfgs)
 	// Iterate over the handlers in the configuration.
 	for _, c := range mCfgs {
 		rmp, ok := handlerProviders[c.Handler.Type]
 		if !ok {
-			return fmt.Errorf("invalid module : %s", c.Name)
+			return nil, fmt.Errorf("invalid module : %s", c.Name)
 		}
 		h, err := rmp(ctx, mgr, &c.Handler)
 		if err != nil {
-			return fmt.Errorf("%s : %w", c.Name, err)
+			return nil, fmt.Errorf("%s : %w", c.Name, err)
+		}
+		if hc, ok := h.(handler.Closer); ok {
+			closers = append(closers, hc)
+		}
+		if dc, ok := h.(handler.DependencyChecker); ok {
+			checkers[c.Name] = dc
 		}
 		h, err = addMiddleware(ctx, mgr, h, &c.Handler)
 		if err != nil {
-			return fmt.Errorf("failed to add middleware: %w", err)
+			return nil, fmt.Errorf("failed to add middleware: %w", err)
 
 		}
+		h = bodyMiddleware(c.MaxBodyBytes, h)
+		h = metricsMiddleware(c.Name, c.Handler.Role, h)
+		h = traceMiddleware(c.Name, h)
 		h = moduleCtxMiddleware(c.Name, h)
 		log.Debugf(ctx, "Registering handler %s, of type %s @ %s", c.Name, c.Handler.Type, c.Path)
 		mux.Handle(c.Path, h)
 	}
-	return nil
+	mux.Handle("/ready", handler.ReadyHandler(checkers))
+	return closeAll, nil
 }
 
 // addMiddleware applies middleware plugins to the provided handler in reverse order.
@@ -81,4 +122,143 @@ func moduleCtxMiddleware(moduleName string, next http.Handler) http.Handler {
 		ctx := context.WithValue(r.Context(), model.ContextKeyModuleID, moduleName)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
-}
\ No newline at end of file
+}
+
+// bodyPool holds the buffers that request bodies are read into.
+var bodyPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// maxPooledBuffer is the capacity above which a buffer is not returned to bodyPool,
+// so that a single large request does not keep its memory in use.
+const maxPooledBuffer = 1 << 20
+
+// bodyMiddleware reads the request body once into a pooled buffer that the middlewares
+// and the handler share, and NACKs requests whose body exceeds maxBytes with 413.
+// The Beckn context of the body is parsed once and passed on for them to reuse.
+// The buffer is reused once the request completes, so anything that reads the body
+// after that, such as an outbound transport, must use a copy. A maxBytes of zero means no limit.
+func bodyMiddleware(maxBytes int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if maxBytes > 0 && r.ContentLength > maxBytes {
+			rejectBody(ctx, w, maxBytes)
+			return
+		}
+
+		buf := bodyPool.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer func() {
+			if buf.Cap() <= maxPooledBuffer {
+				bodyPool.Put(buf)
+			}
+		}()
+		var src io.Reader = r.Body
+		if maxBytes > 0 {
+			// Read one byte more than the limit to detect larger bodies.
+			src = io.LimitReader(r.Body, maxBytes+1)
+		}
+		_, err := buf.ReadFrom(src)
+		r.Body.Close()
+		if err != nil {
+			log.Errorf(ctx, err, "Failed to read request body")
+			response.SendNack(ctx, w, model.NewBadReqErr(err))
+			return
+		}
+		if maxBytes > 0 && int64(buf.Len()) > maxBytes {
+			rejectBody(ctx, w, maxBytes)
+			return
+		}
+		r.Body = model.NewBufferedBody(buf.Bytes())
+		if bc, err := model.BecknContextFor(ctx, buf.Bytes()); err == nil {
+			r = r.WithContext(model.WithBecknContext(ctx, buf.Bytes(), bc))
+			if slot, ok := ctx.Value(becknContextSlot{}).(*model.BecknContext); ok {
+				*slot = *bc
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rejectBody NACKs a request whose body exceeds maxBytes.
+func rejectBody(ctx context.Context, w http.ResponseWriter, maxBytes int64) {
+	err := model.NewPayloadTooLargeErr(fmt.Errorf("request body exceeds %d bytes", maxBytes))
+	log.Errorf(ctx, err, "Rejected request body")
+	response.SendNack(ctx, w, err)
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code int
+}
+
+// WriteHeader records the status code before writing it.
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write records an implicit 200 status if none was written.
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.code == 0 {
+		r.code = http.StatusOK
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// becknContextSlot is the context key of the BecknContext that bodyMiddleware fills
+// in for metricsMiddleware, which runs before the body is read.
+type becknContextSlot struct{}
+
+// metricsMiddleware records request metrics for the module, labelled by the Beckn
+// action of the request path that the router routes on. NACKs are also counted
+// against the counterparty: the BPP for BAP modules and the BAP otherwise. It runs
+// before the body is read, so that requests rejected for their body are counted too.
+func metricsMiddleware(moduleName string, role model.Role, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		bc := &model.BecknContext{}
+		r = r.WithContext(context.WithValue(r.Context(), becknContextSlot{}, bc))
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		if rec.code == 0 {
+			rec.code = http.StatusOK
+		}
+		counterparty := bc.BAPID
+		if role == model.RoleBAP {
+			counterparty = bc.BPPID
+		}
+		metrics.ObserveRequest(moduleName, path.Base(r.URL.Path), counterparty, rec.code, time.Since(start))
+	})
+}
+
+// traceMiddleware starts the module span, continuing the trace from the caller's traceparent header.
+func traceMiddleware(moduleName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := tracing.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, "module "+moduleName,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("onix.module", moduleName),
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			))
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+		if rec.code == 0 {
+			rec.code = http.StatusOK
+		}
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.code))
+		if rec.code >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.code))
+		}
+	})
+}
@@ -3,11 +3,14 @@ package module
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/beckn-one/beckn-onix/core/module/handler"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	"github.com/beckn-one/beckn-onix/pkg/plugin"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
@@ -59,6 +62,11 @@ func (m *mockPluginManager) Cache(ctx context.Context, cfg *plugin.Config) (defi
 	return nil, nil
 }
 
+// Registry returns a mock registry lookup implementation.
+func (m *mockPluginManager) Registry(ctx context.Context, cfg *plugin.Config) (definition.RegistryLookup, error) {
+	return nil, nil
+}
+
 // KeyManager returns a mock key manager implementation.
 func (m *mockPluginManager) KeyManager(ctx context.Context, cache definition.Cache, rLookup definition.RegistryLookup, cfg *plugin.Config) (definition.KeyManager, error) {
 	return nil, nil
@@ -95,10 +103,11 @@ func TestRegisterSuccess(t *testing.T) {
 	}
 
 	mux := http.NewServeMux()
-	err := Register(context.Background(), mCfgs, mux, mockManager)
+	closer, err := Register(context.Background(), mCfgs, mux, mockManager)
 	if err != nil {
-		t.Errorf("unexpected error: %v", err)
+		t.Fatalf("unexpected error: %v", err)
 	}
+	defer closer()
 
 	// Create a request and a response recorder
 	req := httptest.NewRequest(http.MethodGet, "/test", nil)
@@ -127,6 +136,104 @@ func TestRegisterSuccess(t *testing.T) {
 		t.Errorf("handler for /health returned wrong status code: got %v want %v",
 			status, http.StatusOK)
 	}
+
+	// Verifying /metrics endpoint registration
+	recMetrics := httptest.NewRecorder()
+	mux.ServeHTTP(recMetrics, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if status := recMetrics.Code; status != http.StatusOK {
+		t.Errorf("handler for /metrics returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+
+	// Verifying /ready endpoint registration
+	recReady := httptest.NewRecorder()
+	mux.ServeHTTP(recReady, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	if status := recReady.Code; status != http.StatusOK {
+		t.Errorf("handler for /ready returned wrong status code: got %v want %v",
+			status, http.StatusOK)
+	}
+}
+
+// TestMetricsMiddleware tests that NACKs are recorded by the action of the path and
+// the counterparty, including requests rejected for the size of their body.
+func TestMetricsMiddleware(t *testing.T) {
+	body := `{"context":{"action":"search","bap_id":"bap1","bpp_id":"bpp1"}}`
+	var gotBody string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	h := metricsMiddleware("metrics-module", model.RoleBAP, bodyMiddleware(int64(len(body)), next))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bap/caller/search", strings.NewReader(body)))
+	bpp := metricsMiddleware("metrics-module-bpp", model.RoleBPP, bodyMiddleware(0, next))
+	bpp.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bpp/receiver/search", strings.NewReader(body)))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bap/caller/confirm", strings.NewReader(body+" ")))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/bap/caller/x-1234", strings.NewReader(body)))
+
+	if gotBody != body {
+		t.Errorf("handler body = %q, want %q", gotBody, body)
+	}
+	rec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`onix_nacks_total{action="search",counterparty="bpp1",module="metrics-module"} 1`,
+		`onix_nacks_total{action="search",counterparty="bap1",module="metrics-module-bpp"} 1`,
+		`onix_requests_total{action="confirm",code="413",module="metrics-module",outcome="nack"} 1`,
+		`onix_nacks_total{action="confirm",counterparty="",module="metrics-module"} 1`,
+		`onix_nacks_total{action="other",counterparty="bpp1",module="metrics-module"} 1`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics output missing %s", want)
+		}
+	}
+}
+
+// TestBodyMiddleware tests that request bodies are buffered once and limited in size.
+func TestBodyMiddleware(t *testing.T) {
+	body := `{"context":{"action":"search"}}`
+	tests := []struct {
+		name          string
+		maxBytes      int64
+		contentLength int64
+		wantStatus    int
+	}{
+		{name: "no limit", wantStatus: http.StatusOK},
+		{name: "within limit", maxBytes: int64(len(body)), wantStatus: http.StatusOK},
+		{name: "over limit", maxBytes: int64(len(body)) - 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "unknown length over limit", maxBytes: 8, contentLength: -1, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var first, second []byte
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// Middlewares and the handler share the buffered body.
+				first, _ = model.ReadBody(r)
+				second, _ = model.ReadBody(r)
+				w.WriteHeader(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodPost, "/bap/caller/search", strings.NewReader(body))
+			if tt.contentLength != 0 {
+				req.ContentLength = tt.contentLength
+			}
+			rec := httptest.NewRecorder()
+			bodyMiddleware(tt.maxBytes, next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if !strings.Contains(rec.Body.String(), `"status":"NACK"`) {
+					t.Errorf("body = %s, want a NACK", rec.Body.String())
+				}
+				return
+			}
+			if string(first) != body || &first[0] != &second[0] {
+				t.Errorf("handler bodies = %q, %q, want one shared copy of %q", first, second, body)
+			}
+		})
+	}
 }
 
 // TestRegisterFailure tests scenarios where the handler registration should fail.
@@ -174,7 +281,7 @@ func TestRegisterFailure(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mux := http.NewServeMux()
-			err := Register(context.Background(), tt.mCfgs, mux, tt.mockManager)
+			_, err := Register(context.Background(), tt.mCfgs, mux, tt.mockManager)
 			if err == nil {
 				t.Errorf("expected an error but got nil")
 			}
@@ -16,6 +16,7 @@ plugins=(
     "registry"
     "dediregistry"
     "reqpreprocessor"
+    "ratelimiter"
     "router"
     "schemavalidator"
     "schemav2validator"
@@ -0,0 +1,252 @@
+// Package metrics exposes Prometheus metrics for the adapter.
+//
+// The collectors are package-level so that the core handlers and the plugins,
+// which share this package when loaded into the adapter, report into the same registry.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+const namespace = "onix"
+
+// Outcome values reported for requests.
+const (
+	OutcomeACK  = "ack"
+	OutcomeNACK = "nack"
+)
+
+// actionOther is the action label of requests for anything but a Beckn action, so
+// that clients cannot create a series for every path or action they send.
+const actionOther = "other"
+
+// becknActions are the actions reported by name in action labels.
+var becknActions = map[string]bool{
+	"search": true, "select": true, "init": true, "confirm": true,
+	"status": true, "track": true, "cancel": true, "update": true,
+	"rating": true, "support": true, "discover": true,
+	"on_search": true, "on_select": true, "on_init": true, "on_confirm": true,
+	"on_status": true, "on_track": true, "on_cancel": true, "on_update": true,
+	"on_rating": true, "on_support": true, "on_discover": true,
+}
+
+// actionLabel returns action if it is a Beckn action and actionOther otherwise.
+func actionLabel(action string) string {
+	if becknActions[action] {
+		return action
+	}
+	return actionOther
+}
+
+// maxCounterparties is the number of counterparties reported by subscriber ID. The
+// IDs come from request bodies, so later counterparties are reported as "other" to
+// bound the number of series.
+const maxCounterparties = 500
+
+// counterparties holds the counterparties reported by subscriber ID.
+var counterparties = struct {
+	sync.Mutex
+	seen map[string]bool
+}{seen: map[string]bool{}}
+
+// counterpartyLabel returns id if it is one of the first maxCounterparties
+// counterparties seen and "other" otherwise.
+func counterpartyLabel(id string) string {
+	if id == "" {
+		return ""
+	}
+	counterparties.Lock()
+	defer counterparties.Unlock()
+	if counterparties.seen[id] {
+		return id
+	}
+	if len(counterparties.seen) >= maxCounterparties {
+		return "other"
+	}
+	counterparties.seen[id] = true
+	return id
+}
+
+var (
+	registry = prometheus.NewRegistry()
+
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "requests_total",
+		Help:      "Requests handled, by module, Beckn action, status code and outcome.",
+	}, []string{"module", "action", "code", "outcome"})
+
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to handle requests, by module and Beckn action.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"module", "action"})
+
+	nacksTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "nacks_total",
+		Help:      "NACK responses, by module, Beckn action and counterparty subscriber ID.",
+	}, []string{"module", "action", "counterparty"})
+
+	stepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "step_duration_seconds",
+		Help:      "Time taken by processing steps, by module and step name.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"module", "step"})
+
+	stepFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "step_failures_total",
+		Help:      "Processing step failures, by module and step name.",
+	}, []string{"module", "step"})
+
+	outboundDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "outbound_request_duration_seconds",
+		Help:      "Time taken by requests forwarded to target hosts, by host.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"host"})
+
+	outboundResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbound_responses_total",
+		Help:      "Responses from target hosts, by host and status code. Failed requests are reported with code \"error\".",
+	}, []string{"host", "code"})
+
+	registryLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "registry_lookups_total",
+		Help:      "Registry lookups made by key managers, by result.",
+	}, []string{"result"})
+
+	keyCache = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "key_cache_requests_total",
+		Help:      "Public key cache lookups made by key managers, by result (hit or miss).",
+	}, []string{"result"})
+
+	duplicates = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "duplicate_messages_total",
+		Help:      "Duplicate messages detected by the idempotency step, by module and Beckn action.",
+	}, []string{"module", "action"})
+
+	sequenceViolations = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sequence_violations_total",
+		Help:      "Out-of-order messages detected by the validateSequence step, by module and Beckn action.",
+	}, []string{"module", "action"})
+
+	unexpectedCallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "unexpected_callbacks_total",
+		Help:      "Callbacks detected by the validateCallback step that were not expected, by module, Beckn action and reason (unsolicited or late).",
+	}, []string{"module", "action", "reason"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		nacksTotal,
+		stepDuration,
+		stepFailures,
+		outboundDuration,
+		outboundResponses,
+		registryLookups,
+		keyCache,
+		duplicates,
+		sequenceViolations,
+		unexpectedCallbacks,
+	)
+}
+
+// Handler returns the HTTP handler that serves the metrics in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// moduleName returns the module name set on the context, if any.
+func moduleName(ctx context.Context) string {
+	name, _ := ctx.Value(model.ContextKeyModuleID).(string)
+	return name
+}
+
+// ObserveRequest records a handled request. A NACK is also counted against the
+// counterparty, which is empty for requests without a Beckn context.
+func ObserveRequest(module, action, counterparty string, code int, d time.Duration) {
+	action = actionLabel(action)
+	outcome := OutcomeACK
+	if code < http.StatusOK || code >= http.StatusMultipleChoices {
+		outcome = OutcomeNACK
+		nacksTotal.WithLabelValues(module, action, counterpartyLabel(counterparty)).Inc()
+	}
+	requestsTotal.WithLabelValues(module, action, strconv.Itoa(code), outcome).Inc()
+	requestDuration.WithLabelValues(module, action).Observe(d.Seconds())
+}
+
+// ObserveStep records the duration and outcome of a processing step.
+func ObserveStep(ctx context.Context, step string, d time.Duration, err error) {
+	module := moduleName(ctx)
+	stepDuration.WithLabelValues(module, step).Observe(d.Seconds())
+	if err != nil {
+		stepFailures.WithLabelValues(module, step).Inc()
+	}
+}
+
+// ObserveOutbound records a request forwarded to host. code is 0 if no response was received.
+func ObserveOutbound(host string, code int, d time.Duration) {
+	label := "error"
+	if code > 0 {
+		label = strconv.Itoa(code)
+	}
+	outboundDuration.WithLabelValues(host).Observe(d.Seconds())
+	outboundResponses.WithLabelValues(host, label).Inc()
+}
+
+// ObserveRegistryLookup records a registry lookup made by a key manager.
+func ObserveRegistryLookup(err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	registryLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveKeyCache records a public key cache hit or miss in a key manager.
+func ObserveKeyCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	keyCache.WithLabelValues(result).Inc()
+}
+
+// ObserveDuplicate records a duplicate message detected for action.
+func ObserveDuplicate(ctx context.Context, action string) {
+	duplicates.WithLabelValues(moduleName(ctx), actionLabel(action)).Inc()
+}
+
+// ObserveSequenceViolation records an out-of-order message detected for action.
+func ObserveSequenceViolation(ctx context.Context, action string) {
+	sequenceViolations.WithLabelValues(moduleName(ctx), actionLabel(action)).Inc()
+}
+
+// ObserveUnexpectedCallback records a callback for action that was not expected for reason.
+func ObserveUnexpectedCallback(ctx context.Context, action, reason string) {
+	unexpectedCallbacks.WithLabelValues(moduleName(ctx), actionLabel(action), reason).Inc()
+}
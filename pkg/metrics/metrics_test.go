@@ -0,0 +1,150 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// TestObserveRequest tests that requests are counted by outcome and action, with
+// unknown actions reported as "other", and NACKs by counterparty.
+func TestObserveRequest(t *testing.T) {
+	tests := []struct {
+		name        string
+		action      string
+		code        int
+		wantAction  string
+		wantOutcome string
+		wantNacks   float64
+	}{
+		{name: "ack", action: "search", code: http.StatusOK, wantAction: "search", wantOutcome: OutcomeACK, wantNacks: 0},
+		{name: "nack", action: "on_search", code: http.StatusUnauthorized, wantAction: "on_search", wantOutcome: OutcomeNACK, wantNacks: 1},
+		{name: "unknown action", action: "x-1234", code: http.StatusNotFound, wantAction: actionOther, wantOutcome: OutcomeNACK, wantNacks: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			module := "test-request-" + tt.name
+			ObserveRequest(module, tt.action, "bpp.example.com", tt.code, time.Millisecond)
+
+			if got := testutil.ToFloat64(requestsTotal.WithLabelValues(module, tt.wantAction, strconv.Itoa(tt.code), tt.wantOutcome)); got != 1 {
+				t.Errorf("requests_total = %v, want 1", got)
+			}
+			if got := testutil.ToFloat64(nacksTotal.WithLabelValues(module, tt.wantAction, "bpp.example.com")); got != tt.wantNacks {
+				t.Errorf("nacks_total = %v, want %v", got, tt.wantNacks)
+			}
+		})
+	}
+}
+
+// TestCounterpartyLabel tests that counterparties beyond maxCounterparties are
+// reported as "other" while those already seen keep their label.
+func TestCounterpartyLabel(t *testing.T) {
+	seen := counterparties.seen
+	counterparties.seen = map[string]bool{}
+	t.Cleanup(func() { counterparties.seen = seen })
+
+	if got := counterpartyLabel("first.example.com"); got != "first.example.com" {
+		t.Fatalf("counterpartyLabel() = %q, want first.example.com", got)
+	}
+	for i := 0; i < maxCounterparties; i++ {
+		counterpartyLabel(fmt.Sprintf("bpp-%d.example.com", i))
+	}
+	if got := counterpartyLabel("late.example.com"); got != "other" {
+		t.Errorf("counterpartyLabel() = %q beyond the limit, want other", got)
+	}
+	if got := counterpartyLabel("first.example.com"); got != "first.example.com" {
+		t.Errorf("counterpartyLabel() = %q for a known counterparty, want first.example.com", got)
+	}
+	if got := counterpartyLabel(""); got != "" {
+		t.Errorf("counterpartyLabel(\"\") = %q, want empty", got)
+	}
+}
+
+// TestObserveStep tests that step failures are counted against the module on the context.
+func TestObserveStep(t *testing.T) {
+	ctx := context.WithValue(context.Background(), model.ContextKeyModuleID, "test-step")
+
+	ObserveStep(ctx, "validateSign", time.Millisecond, nil)
+	ObserveStep(ctx, "validateSign", time.Millisecond, errors.New("invalid signature"))
+
+	if got := testutil.ToFloat64(stepFailures.WithLabelValues("test-step", "validateSign")); got != 1 {
+		t.Errorf("step_failures_total = %v, want 1", got)
+	}
+}
+
+// TestObserveOutbound tests that failed outbound requests are reported with code "error".
+func TestObserveOutbound(t *testing.T) {
+	ObserveOutbound("test-outbound:8080", http.StatusOK, time.Millisecond)
+	ObserveOutbound("test-outbound:8080", 0, time.Millisecond)
+
+	for _, code := range []string{"200", "error"} {
+		if got := testutil.ToFloat64(outboundResponses.WithLabelValues("test-outbound:8080", code)); got != 1 {
+			t.Errorf("outbound_responses_total{code=%q} = %v, want 1", code, got)
+		}
+	}
+}
+
+// TestObserveDuplicate tests that duplicate messages are counted against the module on the context.
+func TestObserveDuplicate(t *testing.T) {
+	ctx := context.WithValue(context.Background(), model.ContextKeyModuleID, "test-duplicate")
+	ObserveDuplicate(ctx, "confirm")
+	ObserveDuplicate(ctx, "confirm")
+
+	if got := testutil.ToFloat64(duplicates.WithLabelValues("test-duplicate", "confirm")); got != 2 {
+		t.Errorf("duplicate_messages_total = %v, want 2", got)
+	}
+}
+
+// TestObserveSequenceViolation tests that out-of-order messages are counted against the module on the context.
+func TestObserveSequenceViolation(t *testing.T) {
+	ctx := context.WithValue(context.Background(), model.ContextKeyModuleID, "test-sequence")
+	ObserveSequenceViolation(ctx, "confirm")
+
+	if got := testutil.ToFloat64(sequenceViolations.WithLabelValues("test-sequence", "confirm")); got != 1 {
+		t.Errorf("sequence_violations_total = %v, want 1", got)
+	}
+}
+
+// TestObserveUnexpectedCallback tests that unexpected callbacks are counted by reason.
+func TestObserveUnexpectedCallback(t *testing.T) {
+	ctx := context.WithValue(context.Background(), model.ContextKeyModuleID, "test-callback")
+	ObserveUnexpectedCallback(ctx, "on_select", "unsolicited")
+	ObserveUnexpectedCallback(ctx, "on_select", "late")
+
+	for _, reason := range []string{"unsolicited", "late"} {
+		if got := testutil.ToFloat64(unexpectedCallbacks.WithLabelValues("test-callback", "on_select", reason)); got != 1 {
+			t.Errorf("unexpected_callbacks_total{reason=%q} = %v, want 1", reason, got)
+		}
+	}
+}
+
+// TestHandler tests that the metrics endpoint exposes the adapter series.
+func TestHandler(t *testing.T) {
+	ObserveKeyCache(true)
+	ObserveRegistryLookup(nil)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Handler() status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	for _, want := range []string{
+		`onix_key_cache_requests_total{result="hit"}`,
+		`onix_registry_lookups_total{result="success"}`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("Handler() output missing %s", want)
+		}
+	}
+}
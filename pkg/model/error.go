@@ -111,3 +111,93 @@ func (e *NotFoundErr) BecknError() *Error {
 		Message: "Endpoint not found: " + e.Error(),
 	}
 }
+
+// UnavailableErr occurs when an upstream service cannot be reached.
+type UnavailableErr struct {
+	error
+}
+
+// NewUnavailableErr creates a new instance of UnavailableErr from an error.
+func NewUnavailableErr(err error) *UnavailableErr {
+	return &UnavailableErr{err}
+}
+
+// BecknError converts the UnavailableErr to an instance of Error.
+func (e *UnavailableErr) BecknError() *Error {
+	return &Error{
+		Code:    http.StatusText(http.StatusServiceUnavailable),
+		Message: "Service Unavailable: " + e.Error(),
+	}
+}
+
+// BadGatewayErr occurs when an upstream service returns an invalid response.
+type BadGatewayErr struct {
+	error
+}
+
+// NewBadGatewayErr creates a new instance of BadGatewayErr from an error.
+func NewBadGatewayErr(err error) *BadGatewayErr {
+	return &BadGatewayErr{err}
+}
+
+// BecknError converts the BadGatewayErr to an instance of Error.
+func (e *BadGatewayErr) BecknError() *Error {
+	return &Error{
+		Code:    http.StatusText(http.StatusBadGateway),
+		Message: "Bad Gateway: " + e.Error(),
+	}
+}
+
+// PayloadTooLargeErr occurs when a request body exceeds the size limit of the module.
+type PayloadTooLargeErr struct {
+	error
+}
+
+// NewPayloadTooLargeErr creates a new instance of PayloadTooLargeErr from an error.
+func NewPayloadTooLargeErr(err error) *PayloadTooLargeErr {
+	return &PayloadTooLargeErr{err}
+}
+
+// BecknError converts the PayloadTooLargeErr to an instance of Error.
+func (e *PayloadTooLargeErr) BecknError() *Error {
+	return &Error{
+		Code:    http.StatusText(http.StatusRequestEntityTooLarge),
+		Message: "Payload Too Large: " + e.Error(),
+	}
+}
+
+// TooManyRequestsErr occurs when a subscriber exceeds its rate limit.
+type TooManyRequestsErr struct {
+	error
+}
+
+// NewTooManyRequestsErr creates a new instance of TooManyRequestsErr from an error.
+func NewTooManyRequestsErr(err error) *TooManyRequestsErr {
+	return &TooManyRequestsErr{err}
+}
+
+// BecknError converts the TooManyRequestsErr to an instance of Error.
+func (e *TooManyRequestsErr) BecknError() *Error {
+	return &Error{
+		Code:    http.StatusText(http.StatusTooManyRequests),
+		Message: "Too Many Requests: " + e.Error(),
+	}
+}
+
+// ReplayErr occurs when a signed request that was already accepted is received again.
+type ReplayErr struct {
+	error
+}
+
+// NewReplayErr creates a new instance of ReplayErr from an error.
+func NewReplayErr(err error) *ReplayErr {
+	return &ReplayErr{err}
+}
+
+// BecknError converts the ReplayErr to an instance of Error.
+func (e *ReplayErr) BecknError() *Error {
+	return &Error{
+		Code:    http.StatusText(http.StatusConflict),
+		Message: "Replayed Request: " + e.Error(),
+	}
+}
@@ -166,6 +166,81 @@ func TestNewNotFoundErr(t *testing.T) {
 	}
 }
 
+func TestUnavailableErr_BecknError(t *testing.T) {
+	unavailableErr := NewUnavailableErr(errors.New("circuit open"))
+	beErr := unavailableErr.BecknError()
+
+	expectedMsg := "Service Unavailable: circuit open"
+	if beErr.Message != expectedMsg {
+		t.Errorf("err.Error() = %s, want %s",
+			beErr.Message, expectedMsg)
+	}
+	if beErr.Code != http.StatusText(http.StatusServiceUnavailable) {
+		t.Errorf("err.Code = %s, want %s",
+			beErr.Code, http.StatusText(http.StatusServiceUnavailable))
+	}
+}
+
+func TestReplayErr_BecknError(t *testing.T) {
+	replayErr := NewReplayErr(errors.New("duplicate signature"))
+	beErr := replayErr.BecknError()
+
+	expectedMsg := "Replayed Request: duplicate signature"
+	if beErr.Message != expectedMsg {
+		t.Errorf("err.Error() = %s, want %s",
+			beErr.Message, expectedMsg)
+	}
+	if beErr.Code != http.StatusText(http.StatusConflict) {
+		t.Errorf("err.Code = %s, want %s",
+			beErr.Code, http.StatusText(http.StatusConflict))
+	}
+}
+
+func TestBadGatewayErr_BecknError(t *testing.T) {
+	gwErr := NewBadGatewayErr(errors.New("invalid response"))
+	beErr := gwErr.BecknError()
+
+	expectedMsg := "Bad Gateway: invalid response"
+	if beErr.Message != expectedMsg {
+		t.Errorf("err.Error() = %s, want %s",
+			beErr.Message, expectedMsg)
+	}
+	if beErr.Code != http.StatusText(http.StatusBadGateway) {
+		t.Errorf("err.Code = %s, want %s",
+			beErr.Code, http.StatusText(http.StatusBadGateway))
+	}
+}
+
+func TestPayloadTooLargeErr_BecknError(t *testing.T) {
+	sizeErr := NewPayloadTooLargeErr(errors.New("body exceeds 1024 bytes"))
+	beErr := sizeErr.BecknError()
+
+	expectedMsg := "Payload Too Large: body exceeds 1024 bytes"
+	if beErr.Message != expectedMsg {
+		t.Errorf("err.Error() = %s, want %s",
+			beErr.Message, expectedMsg)
+	}
+	if beErr.Code != http.StatusText(http.StatusRequestEntityTooLarge) {
+		t.Errorf("err.Code = %s, want %s",
+			beErr.Code, http.StatusText(http.StatusRequestEntityTooLarge))
+	}
+}
+
+func TestTooManyRequestsErr_BecknError(t *testing.T) {
+	limitErr := NewTooManyRequestsErr(errors.New("rate limit exceeded"))
+	beErr := limitErr.BecknError()
+
+	expectedMsg := "Too Many Requests: rate limit exceeded"
+	if beErr.Message != expectedMsg {
+		t.Errorf("err.Error() = %s, want %s",
+			beErr.Message, expectedMsg)
+	}
+	if beErr.Code != http.StatusText(http.StatusTooManyRequests) {
+		t.Errorf("err.Code = %s, want %s",
+			beErr.Code, http.StatusText(http.StatusTooManyRequests))
+	}
+}
+
 func TestRole_UnmarshalYAML_ValidRole(t *testing.T) {
 	var role Role
 	yamlData := []byte("bap")
@@ -1,8 +1,12 @@
 package model
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"time"
@@ -14,6 +18,7 @@ type Subscriber struct {
 	URL          string `json:"url,omitzero" format:"uri"`
 	Type         string `json:"type,omitzero" enum:"BAP,BPP,BG"`
 	Domain       string `json:"domain,omitzero"`
+	City         string `json:"city,omitzero"`
 }
 
 // Subscription represents subscription details of a network participant.
@@ -126,9 +131,11 @@ func (r *Role) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Route represents a network route for message processing.
 type Route struct {
-	TargetType  string   // "url" or "publisher"
-	PublisherID string   // For message queues
-	URL         *url.URL // For API calls
+	TargetType  string      // "url", "publisher" or "broadcast"
+	PublisherID string      // For message queues
+	URL         *url.URL    // For API calls
+	URLs        []*url.URL  // For broadcasting one request to several endpoints
+	Lookup      *Subscriber // For broadcasting to the registry subscribers that match these fields
 }
 
 // Keyset represents a collection of cryptographic keys used for signing and encryption.
@@ -141,15 +148,177 @@ type Keyset struct {
 	EncrPublic     string // EncrPublic is the public key corresponding to the encryption private key.
 }
 
+// BufferedBody is a request body that was read into memory once, so that the
+// middlewares and the handler of a module share it instead of each copying it.
+type BufferedBody struct {
+	*bytes.Reader
+	data []byte
+}
+
+// NewBufferedBody returns a request body that reads data.
+func NewBufferedBody(data []byte) *BufferedBody {
+	return &BufferedBody{Reader: bytes.NewReader(data), data: data}
+}
+
+// Bytes returns the whole body, regardless of how much of it was read.
+func (b *BufferedBody) Bytes() []byte {
+	return b.data
+}
+
+// Close does nothing, as the body is held in memory.
+func (b *BufferedBody) Close() error {
+	return nil
+}
+
+// ReadBody returns the body of r. A BufferedBody is returned without copying. Any
+// other body is read and replaced with a BufferedBody, so that later readers share it.
+func ReadBody(r *http.Request) ([]byte, error) {
+	if b, ok := r.Body.(*BufferedBody); ok {
+		return b.data, nil
+	}
+	if r.Body == nil {
+		return nil, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	r.Body = NewBufferedBody(data)
+	return data, nil
+}
+
+// RouteResult is the outcome of handling a request, including requests that were
+// rejected by a processing step before routing.
+type RouteResult struct {
+	Status         int           // Status of the ACK or NACK sent to the caller
+	UpstreamStatus int           // Status of the upstream response for url and broadcast routes, 0 if there was none, as for asynchronous delivery
+	Latency        time.Duration // Time taken to forward, broadcast, publish or queue the request, 0 if it was not routed
+	Err            error         // Error from a processing step, forwarding or publishing; nil if the request was accepted
+}
+
 // StepContext holds context information for a request processing step.
 type StepContext struct {
 	context.Context
-	Request    *http.Request
-	Body       []byte
-	Route      *Route
-	SubID      string
-	Role       Role
-	RespHeader http.Header
+	Request     *http.Request
+	Body        []byte
+	Route       *Route
+	SubID       string
+	Role        Role
+	RespHeader  http.Header
+	RouteResult *RouteResult // Set after routing, for post-routing steps
+
+	becknCtx *parsedBecknContext // Beckn context of Body, parsed on first use
+}
+
+// BecknContext returns the Beckn context of the request body. It is parsed on first
+// use and shared by all steps and plugins until the body is replaced.
+func (ctx *StepContext) BecknContext() (*BecknContext, error) {
+	if ctx.becknCtx == nil || !sameBytes(ctx.becknCtx.body, ctx.Body) {
+		ctx.becknCtx = parsedBecknContextFor(ctx.Context, ctx.Body)
+	}
+	return ctx.becknCtx.bc, ctx.becknCtx.err
+}
+
+// BecknContext is the context of a Beckn message.
+type BecknContext struct {
+	Domain        string          `json:"domain"`
+	Version       string          `json:"version"`
+	Action        string          `json:"action"`
+	BAPID         string          `json:"bap_id"`
+	BAPURI        string          `json:"bap_uri"`
+	BPPID         string          `json:"bpp_id"`
+	BPPURI        string          `json:"bpp_uri"`
+	TransactionID string          `json:"transaction_id"`
+	MessageID     string          `json:"message_id"`
+	Timestamp     string          `json:"timestamp"` // RFC 3339 time at which the message was sent
+	TTL           string          `json:"ttl"`       // ISO 8601 duration for which the message is valid
+	City          string          `json:"city"`      // City code in protocol versions before 1.1
+	Location      ContextLocation `json:"location"`  // Location of the transaction
+}
+
+// ContextLocation is the location in the context of a Beckn message.
+type ContextLocation struct {
+	City struct {
+		Code string `json:"code"`
+	} `json:"city"`
+}
+
+// CityCode returns the city code of the message, from context.location.city.code or,
+// for older protocol versions, context.city.
+func (c *BecknContext) CityCode() string {
+	if c.Location.City.Code != "" {
+		return c.Location.City.Code
+	}
+	return c.City
+}
+
+// ErrNoBecknContext is returned for messages without a context object.
+var ErrNoBecknContext = errors.New("context field not found or invalid")
+
+// ParseBecknContext parses the context of a Beckn message. Only the context object
+// is decoded into a BecknContext, the rest of the message is only scanned.
+func ParseBecknContext(body []byte) (*BecknContext, error) {
+	var msg struct {
+		Context json.RawMessage `json:"context"`
+	}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, err
+	}
+	if len(msg.Context) == 0 || msg.Context[0] != '{' {
+		return nil, ErrNoBecknContext
+	}
+	bc := &BecknContext{}
+	if err := json.Unmarshal(msg.Context, bc); err != nil {
+		return nil, err
+	}
+	return bc, nil
+}
+
+// parsedBecknContext is the result of parsing the Beckn context of a body.
+type parsedBecknContext struct {
+	body []byte
+	bc   *BecknContext
+	err  error
+}
+
+// becknContextKey is the context key of the Beckn context parsed for a request.
+type becknContextKey struct{}
+
+// WithBecknContext returns a copy of ctx that carries the Beckn context parsed from
+// body, so that later stages of the request can reuse it.
+func WithBecknContext(ctx context.Context, body []byte, bc *BecknContext) context.Context {
+	return context.WithValue(ctx, becknContextKey{}, &parsedBecknContext{body: body, bc: bc})
+}
+
+// BecknContextFor returns the Beckn context of body. It reuses the context already
+// parsed for the request if ctx is the StepContext of body or carries the context
+// of body, and parses body otherwise.
+func BecknContextFor(ctx context.Context, body []byte) (*BecknContext, error) {
+	if sc, ok := ctx.(*StepContext); ok && sameBytes(sc.Body, body) {
+		return sc.BecknContext()
+	}
+	p := parsedBecknContextFor(ctx, body)
+	return p.bc, p.err
+}
+
+// parsedBecknContextFor returns the Beckn context carried by ctx if it was parsed
+// from the same body, and parses body otherwise.
+func parsedBecknContextFor(ctx context.Context, body []byte) *parsedBecknContext {
+	if ctx != nil {
+		if p, ok := ctx.Value(becknContextKey{}).(*parsedBecknContext); ok && sameBytes(p.body, body) {
+			return p
+		}
+	}
+	bc, err := ParseBecknContext(body)
+	return &parsedBecknContext{body: body, bc: bc, err: err}
+}
+
+// sameBytes reports whether a and b are the same slice, without comparing their
+// contents. A body is replaced rather than modified in place, so a parsed context
+// stays valid for as long as the body it was parsed from is in use.
+func sameBytes(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
 }
 
 // WithContext updates the existing StepContext with a new context.
@@ -184,4 +353,4 @@ type Message struct {
 // Response represents the main response structure.
 type Response struct {
 	Message Message `json:"message"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const becknBody = `{"context":{"domain":"ONDC:RET10","version":"1.2.0","action":"search","bap_id":"bap.example.com","bap_uri":"https://bap.example.com","transaction_id":"txn-1","message_id":"msg-1","timestamp":"2026-01-02T03:04:05Z","ttl":"PT30S"},"message":{"intent":{}}}`
+
+// TestParseBecknContext tests parsing the context of Beckn messages.
+func TestParseBecknContext(t *testing.T) {
+	bc, err := ParseBecknContext([]byte(becknBody))
+	assert.NoError(t, err)
+	assert.Equal(t, &BecknContext{
+		Domain:        "ONDC:RET10",
+		Version:       "1.2.0",
+		Action:        "search",
+		BAPID:         "bap.example.com",
+		BAPURI:        "https://bap.example.com",
+		TransactionID: "txn-1",
+		MessageID:     "msg-1",
+		Timestamp:     "2026-01-02T03:04:05Z",
+		TTL:           "PT30S",
+	}, bc)
+}
+
+// TestBecknContextCityCode tests the city code of current and older protocol versions.
+func TestBecknContextCityCode(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "location", body: `{"context":{"location":{"city":{"code":"std:080"}},"city":"std:011"}}`, want: "std:080"},
+		{name: "older version", body: `{"context":{"city":"std:011"}}`, want: "std:011"},
+		{name: "no city", body: `{"context":{}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc, err := ParseBecknContext([]byte(tt.body))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, bc.CityCode())
+		})
+	}
+}
+
+// TestParseBecknContextFailure tests that invalid messages and contexts are rejected.
+func TestParseBecknContextFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		noContext bool
+	}{
+		{name: "invalid JSON", body: `{invalid`},
+		{name: "missing context", body: `{"message":{}}`, noContext: true},
+		{name: "null context", body: `{"context":null}`, noContext: true},
+		{name: "context not an object", body: `{"context":"search"}`, noContext: true},
+		{name: "invalid field type", body: `{"context":{"action":123}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBecknContext([]byte(tt.body))
+			assert.Error(t, err)
+			assert.Equal(t, tt.noContext, errors.Is(err, ErrNoBecknContext))
+		})
+	}
+}
+
+// TestStepContextBecknContext tests that the Beckn context is parsed once per body.
+func TestStepContextBecknContext(t *testing.T) {
+	ctx := &StepContext{Context: context.Background(), Body: []byte(becknBody)}
+	first, err := ctx.BecknContext()
+	assert.NoError(t, err)
+	second, _ := ctx.BecknContext()
+	assert.Same(t, first, second)
+
+	// Plugins given the StepContext and its body share the parsed context.
+	shared, _ := BecknContextFor(ctx, ctx.Body)
+	assert.Same(t, first, shared)
+
+	// A changed body is parsed again.
+	ctx.Body = []byte(`{"context":{"action":"select"}}`)
+	changed, err := ctx.BecknContext()
+	assert.NoError(t, err)
+	assert.Equal(t, "select", changed.Action)
+}
+
+// TestWithBecknContext tests that a context parsed before the StepContext exists is reused.
+func TestWithBecknContext(t *testing.T) {
+	body := []byte(becknBody)
+	parsed, _ := ParseBecknContext(body)
+	reqCtx := WithBecknContext(context.Background(), body, parsed)
+
+	// The handler reads the body buffered by the middleware.
+	ctx := &StepContext{Context: reqCtx, Body: body}
+	bc, err := ctx.BecknContext()
+	assert.NoError(t, err)
+	assert.Same(t, parsed, bc)
+
+	// A copy of the body is parsed again, without comparing its contents.
+	copied, err := BecknContextFor(reqCtx, append([]byte(nil), body...))
+	assert.NoError(t, err)
+	assert.NotSame(t, parsed, copied)
+	assert.Equal(t, parsed, copied)
+
+	// A different body is not given the carried context.
+	other, err := BecknContextFor(reqCtx, []byte(`{"context":{"action":"init"}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, "init", other.Action)
+}
+
+// TestReadBody tests that a request body is read once and shared by later readers.
+func TestReadBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/search", strings.NewReader(becknBody))
+	first, err := ReadBody(req)
+	assert.NoError(t, err)
+	assert.Equal(t, becknBody, string(first))
+
+	second, err := ReadBody(req)
+	assert.NoError(t, err)
+	assert.Same(t, &first[0], &second[0])
+
+	// The body can still be read as a stream.
+	streamed, err := io.ReadAll(req.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, becknBody, string(streamed))
+}
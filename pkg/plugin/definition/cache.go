@@ -2,12 +2,18 @@ package definition
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrCacheMiss is returned, possibly wrapped, by Cache.Get for a key that is not in the
+// cache, so that callers can tell a missing key from a failure of the cache.
+var ErrCacheMiss = errors.New("cache miss")
+
 // Cache defines the general cache interface for caching plugins.
 type Cache interface {
-	// Get retrieves a value from the cache based on the given key.
+	// Get retrieves a value from the cache based on the given key. It returns an error
+	// wrapping ErrCacheMiss if the key is not in the cache.
 	Get(ctx context.Context, key string) (string, error)
 
 	// Set stores a value in the cache with the given key and TTL (time-to-live) in seconds.
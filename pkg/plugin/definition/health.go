@@ -0,0 +1,10 @@
+package definition
+
+import "context"
+
+// HealthChecker is an optional interface for plugins that depend on external services.
+// Plugins that implement it are checked by the adapter's readiness endpoint.
+type HealthChecker interface {
+	// HealthCheck returns an error if a dependency of the plugin cannot be reached.
+	HealthCheck(ctx context.Context) error
+}
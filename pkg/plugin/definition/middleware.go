@@ -8,3 +8,10 @@ import (
 type MiddlewareProvider interface {
 	New(ctx context.Context, cfg map[string]string) (func(http.Handler) http.Handler, error)
 }
+
+// ClosingMiddlewareProvider is an optional interface for middleware providers whose
+// middleware holds resources, such as connections. The plugin manager creates their
+// middleware with NewWithCloser and calls the close function when it is closed.
+type ClosingMiddlewareProvider interface {
+	NewWithCloser(ctx context.Context, cfg map[string]string) (func(http.Handler) http.Handler, func() error, error)
+}
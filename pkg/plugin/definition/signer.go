@@ -1,6 +1,9 @@
 package definition
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Signer defines the method for signing.
 type Signer interface {
@@ -10,6 +13,13 @@ type Signer interface {
 	Sign(ctx context.Context, body []byte, privateKeyBase64 string, createdAt, expiresAt int64) (string, error)
 }
 
+// TTLProvider is optionally implemented by signers that configure how long the
+// signatures they generate stay valid.
+type TTLProvider interface {
+	// TTL returns the validity period of new signatures, or 0 to use the default.
+	TTL() time.Duration
+}
+
 // SignerProvider initializes a new signer instance with the given config.
 type SignerProvider interface {
 	// New creates a new signer instance based on the provided config.
@@ -1,6 +1,9 @@
 package definition
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // SignValidator defines the method for verifying signatures.
 type SignValidator interface {
@@ -8,6 +11,13 @@ type SignValidator interface {
 	Validate(ctx context.Context, body []byte, header string, publicKeyBase64 string) error
 }
 
+// SkewTolerator is an optional interface for sign validators that accept signatures
+// within a clock skew of their created and expires timestamps.
+type SkewTolerator interface {
+	// AllowedSkew returns the clock skew tolerated by the validator.
+	AllowedSkew() time.Duration
+}
+
 // SignValidatorProvider initializes a new Verifier instance with the given config.
 type SignValidatorProvider interface {
 	// New creates a new Verifier instance based on the provided config.
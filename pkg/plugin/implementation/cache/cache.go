@@ -8,7 +8,11 @@ import (
 	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RedisCl global variable for the Redis client, can be overridden in tests
@@ -83,20 +87,55 @@ func New(ctx context.Context, cfg *Config) (*Cache, func() error, error) {
 
 // Get retrieves the value for the specified key from Redis.
 func (c *Cache) Get(ctx context.Context, key string) (string, error) {
-	return c.Client.Get(ctx, key).Result()
+	_, span := startSpan(ctx, "GET")
+	val, err := c.Client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		// A missing key is a cache miss, not a failure of the call.
+		tracing.End(span, nil)
+		return val, fmt.Errorf("%w: %w", definition.ErrCacheMiss, err)
+	}
+	tracing.End(span, err)
+	return val, err
 }
 
 // Set stores the given key-value pair in Redis with the specified TTL (time to live).
 func (c *Cache) Set(ctx context.Context, key, value string, ttl time.Duration) error {
-	return c.Client.Set(ctx, key, value, ttl).Err()
+	_, span := startSpan(ctx, "SET")
+	err := c.Client.Set(ctx, key, value, ttl).Err()
+	tracing.End(span, err)
+	return err
 }
 
 // Delete removes the specified key from Redis.
 func (c *Cache) Delete(ctx context.Context, key string) error {
-	return c.Client.Del(ctx, key).Err()
+	_, span := startSpan(ctx, "DEL")
+	err := c.Client.Del(ctx, key).Err()
+	tracing.End(span, err)
+	return err
 }
 
 // Clear removes all keys in the currently selected Redis database.
 func (c *Cache) Clear(ctx context.Context) error {
-	return c.Client.FlushDB(ctx).Err()
+	_, span := startSpan(ctx, "FLUSHDB")
+	err := c.Client.FlushDB(ctx).Err()
+	tracing.End(span, err)
+	return err
+}
+
+// HealthCheck pings the Redis server.
+func (c *Cache) HealthCheck(ctx context.Context) error {
+	if err := c.Client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrConnectionFail, err)
+	}
+	return nil
+}
+
+// startSpan starts a client span for a Redis command.
+func startSpan(ctx context.Context, cmd string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "redis "+cmd,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation.name", cmd),
+		))
 }
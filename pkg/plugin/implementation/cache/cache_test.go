@@ -2,10 +2,12 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -60,6 +62,23 @@ func TestCache_Get(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+// TestCache_GetMiss tests that Get tells a missing key from a failure of Redis.
+func TestCache_GetMiss(t *testing.T) {
+	mockClient := new(MockRedisClient)
+	ctx := context.Background()
+	cache := &Cache{Client: mockClient}
+
+	mockClient.On("Get", ctx, "missing").Return("", redis.Nil)
+	mockClient.On("Get", ctx, "failing").Return("", errors.New("connection refused"))
+
+	_, err := cache.Get(ctx, "missing")
+	assert.ErrorIs(t, err, definition.ErrCacheMiss)
+	assert.ErrorIs(t, err, redis.Nil)
+	_, err = cache.Get(ctx, "failing")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, definition.ErrCacheMiss)
+}
+
 // TestCache_Set tests the Set method of the Cache type
 func TestCache_Set(t *testing.T) {
 	mockClient := new(MockRedisClient)
@@ -99,6 +118,20 @@ func TestCache_Clear(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+// TestCache_HealthCheck tests the HealthCheck method of the Cache type
+func TestCache_HealthCheck(t *testing.T) {
+	ctx := context.Background()
+
+	healthy := new(MockRedisClient)
+	healthy.On("Ping", ctx).Return(redis.NewStatusResult("PONG", nil))
+	assert.NoError(t, (&Cache{Client: healthy}).HealthCheck(ctx))
+
+	unhealthy := new(MockRedisClient)
+	unhealthy.On("Ping", ctx).Return(redis.NewStatusResult("", errors.New("connection refused")))
+	err := (&Cache{Client: unhealthy}).HealthCheck(ctx)
+	assert.ErrorIs(t, err, ErrConnectionFail)
+}
+
 // TestValidate tests the validate function
 func TestValidate(t *testing.T) {
 	tests := []struct {
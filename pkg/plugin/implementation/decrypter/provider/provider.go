@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	decrypter "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/decrypter"
+)
+
+// decrypterProvider implements the definition.decrypterProvider interface.
+type decrypterProvider struct{}
+
+// New creates a new Decrypter instance using the provided configuration.
+func (dp decrypterProvider) New(ctx context.Context, config map[string]string) (definition.Decrypter, func() error, error) {
+	return decrypter.New(ctx)
+}
+
+// Provider is the exported symbol that the plugin manager will look for.
+var Provider = decrypterProvider{}
+
+func init() {
+	if err := plugin.Register("decrypter", &Provider); err != nil {
+		log.Errorf(context.Background(), err, "Failed to register decrypter plugin")
+	}
+}
@@ -10,7 +10,10 @@ import (
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 	"github.com/hashicorp/go-retryablehttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config holds configuration parameters for the DeDi registry client.
@@ -75,6 +78,30 @@ func New(ctx context.Context, cfg *Config) (*DeDiRegistryClient, func() error, e
 
 // Lookup implements RegistryLookup interface - calls the DeDi wrapper lookup endpoint and returns Subscription.
 func (c *DeDiRegistryClient) Lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "registry.Lookup",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("onix.subscriber_id", req.SubscriberID)))
+	results, err := c.lookup(ctx, req)
+	tracing.End(span, err)
+	return results, err
+}
+
+// HealthCheck verifies that the DeDi registry is reachable. Any HTTP response counts as reachable.
+func (c *DeDiRegistryClient) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("DeDi registry unreachable: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// lookup performs the registry lookup for Lookup.
+func (c *DeDiRegistryClient) lookup(ctx context.Context, req *model.Subscription) ([]model.Subscription, error) {
 	// Extract subscriber ID and key ID from request (both come from Authorization header parsing)
 	subscriberID := req.SubscriberID
 	keyID := req.KeyID
@@ -1,18 +1,6 @@
 package main
 
-import (
-	"context"
-
-	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
-	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/encrypter"
-)
-
-// encrypterProvider implements the definition.encrypterProvider interface.
-type encrypterProvider struct{}
-
-func (ep encrypterProvider) New(ctx context.Context, config map[string]string) (definition.Encrypter, func() error, error) {
-	return encrypter.New(ctx)
-}
+import "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/encrypter/provider"
 
 // Provider is the exported symbol that the plugin manager will look for.
-var Provider = encrypterProvider{}
+var Provider = provider.Provider
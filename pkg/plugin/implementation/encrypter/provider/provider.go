@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/encrypter"
+)
+
+// encrypterProvider implements the definition.encrypterProvider interface.
+type encrypterProvider struct{}
+
+func (ep encrypterProvider) New(ctx context.Context, config map[string]string) (definition.Encrypter, func() error, error) {
+	return encrypter.New(ctx)
+}
+
+// Provider is the exported symbol that the plugin manager will look for.
+var Provider = encrypterProvider{}
+
+func init() {
+	if err := plugin.Register("encrypter", &Provider); err != nil {
+		log.Errorf(context.Background(), err, "Failed to register encrypter plugin")
+	}
+}
@@ -13,10 +13,14 @@ import (
 	"strings"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 	"github.com/google/uuid"
 	vault "github.com/hashicorp/vault/api"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config holds configuration parameters for connecting to Vault.
@@ -234,7 +238,9 @@ func (km *KeyMgr) InsertKeyset(ctx context.Context, keyID string, keys *model.Ke
 		payload = keyData
 	}
 
+	_, span := startVaultSpan(ctx, "write", path)
 	_, err := km.VaultClient.Logical().Write(path, payload)
+	tracing.End(span, err)
 	if err != nil {
 		return fmt.Errorf("failed to store secret in Vault at path %s: %w", path, err)
 	}
@@ -247,7 +253,10 @@ func (km *KeyMgr) DeleteKeyset(ctx context.Context, keyID string) error {
 		return ErrEmptyKeyID
 	}
 	path := km.getSecretPath(keyID)
-	return km.VaultClient.KVv2(path).Delete(ctx, keyID)
+	ctx, span := startVaultSpan(ctx, "delete", path)
+	err := km.VaultClient.KVv2(path).Delete(ctx, keyID)
+	tracing.End(span, err)
+	return err
 }
 
 // Keyset retrieves the keyset for the given key ID from Vault and public keys from the registry.
@@ -258,7 +267,9 @@ func (km *KeyMgr) Keyset(ctx context.Context, keyID string) (*model.Keyset, erro
 
 	path := km.getSecretPath(keyID)
 
+	_, span := startVaultSpan(ctx, "read", path)
 	secret, err := km.VaultClient.Logical().Read(path)
+	tracing.End(span, err)
 	if err != nil || secret == nil {
 		return nil, fmt.Errorf("failed to read secret from Vault: %w", err)
 	}
@@ -293,15 +304,18 @@ func (km *KeyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueKeyID st
 	if err == nil {
 		var keys model.Keyset
 		if err := json.Unmarshal([]byte(cachedData), &keys); err == nil {
+			metrics.ObserveKeyCache(true)
 			return keys.SigningPublic, keys.EncrPublic, nil
 		}
 	}
+	metrics.ObserveKeyCache(false)
 	subscribers, err := km.Registry.Lookup(ctx, &model.Subscription{
 		Subscriber: model.Subscriber{
 			SubscriberID: subscriberID,
 		},
 		KeyID: uniqueKeyID,
 	})
+	metrics.ObserveRegistryLookup(err)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to lookup registry: %w", err)
 	}
@@ -322,6 +336,24 @@ func validateParams(subscriberID, uniqueKeyID string) error {
 	return nil
 }
 
+// HealthCheck verifies that Vault is reachable and the client token is still valid.
+func (km *KeyMgr) HealthCheck(ctx context.Context) error {
+	ctx, span := startVaultSpan(ctx, "token lookup", "auth/token/lookup-self")
+	_, err := km.VaultClient.Auth().Token().LookupSelfWithContext(ctx)
+	tracing.End(span, err)
+	if err != nil {
+		return fmt.Errorf("vault token lookup failed: %w", err)
+	}
+	return nil
+}
+
+// startVaultSpan starts a client span for a Vault operation on path.
+func startVaultSpan(ctx context.Context, op, path string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, "vault "+op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("vault.path", path)))
+}
+
 // encodeBase64 returns the base64-encoded string of the given data.
 func encodeBase64(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
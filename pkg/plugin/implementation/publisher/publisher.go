@@ -10,6 +10,7 @@ import (
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 	"github.com/rabbitmq/amqp091-go"
 )
 
@@ -45,6 +46,8 @@ var (
 	ErrConnectionFailed  = errors.New("failed to connect to RabbitMQ")
 	ErrChannelFailed     = errors.New("failed to open channel")
 	ErrExchangeDeclare   = errors.New("failed to declare exchange")
+	ErrConnectionClosed  = errors.New("RabbitMQ connection is closed")
+	ErrChannelClosed     = errors.New("RabbitMQ channel is closed")
 )
 
 // Validate checks whether the provided Config is valid for connecting to RabbitMQ.
@@ -97,6 +100,17 @@ func GetConnURL(cfg *Config) (string, error) {
 	return connURL, nil
 }
 
+// HealthCheck reports whether the RabbitMQ connection and channel are still open.
+func (p *Publisher) HealthCheck(ctx context.Context) error {
+	if p.Conn != nil && p.Conn.IsClosed() {
+		return ErrConnectionClosed
+	}
+	if ch, ok := p.Channel.(interface{ IsClosed() bool }); ok && ch.IsClosed() {
+		return ErrChannelClosed
+	}
+	return nil
+}
+
 // Publish sends a message to the configured RabbitMQ exchange with the specified routing key.
 // If routingKey is empty, the default routing key from Config is used.
 func (p *Publisher) Publish(ctx context.Context, routingKey string, msg []byte) error {
@@ -104,6 +118,9 @@ func (p *Publisher) Publish(ctx context.Context, routingKey string, msg []byte)
 		routingKey = p.Config.RoutingKey
 	}
 	log.Debugf(ctx, "Attempting to publish message. Exchange: %s, RoutingKey: %s", p.Config.Exchange, routingKey)
+	// Carry the trace context to consumers in the W3C traceparent message header.
+	headers := amqp091.Table{}
+	tracing.Inject(ctx, tracing.MapCarrier(headers))
 	err := p.Channel.PublishWithContext(
 		ctx,
 		p.Config.Exchange,
@@ -112,6 +129,7 @@ func (p *Publisher) Publish(ctx context.Context, routingKey string, msg []byte)
 		false,
 		amqp091.Publishing{
 			ContentType: "application/json",
+			Headers:     headers,
 			Body:        msg,
 		},
 	)
@@ -6,7 +6,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 	"github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestGetConnURLSuccess(t *testing.T) {
@@ -171,6 +173,7 @@ type mockChannelForPublish struct {
 	exchange  string
 	key       string
 	body      []byte
+	headers   amqp091.Table
 	fail      bool
 }
 
@@ -182,6 +185,7 @@ func (m *mockChannelForPublish) PublishWithContext(ctx context.Context, exchange
 	m.exchange = exchange
 	m.key = key
 	m.body = msg.Body
+	m.headers = msg.Headers
 	return nil
 }
 
@@ -218,6 +222,33 @@ func TestPublishSuccess(t *testing.T) {
 	}
 }
 
+// TestPublishTraceContext tests that the trace context is carried in the message headers.
+func TestPublishTraceContext(t *testing.T) {
+	if _, err := tracing.Init(context.Background(), tracing.Config{}); err != nil {
+		t.Fatalf("tracing.Init() error = %v", err)
+	}
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x01, 0x02, 0x03},
+		SpanID:     trace.SpanID{0x04, 0x05},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+
+	mockCh := &mockChannelForPublish{}
+	p := &Publisher{
+		Channel: mockCh,
+		Config:  &Config{Exchange: "mock.exchange", RoutingKey: "mock.key"},
+	}
+	if err := p.Publish(ctx, "", []byte(`{"test": true}`)); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	want := "00-" + sc.TraceID().String() + "-" + sc.SpanID().String() + "-01"
+	if got := mockCh.headers["traceparent"]; got != want {
+		t.Errorf("traceparent header = %v, want %s", got, want)
+	}
+}
+
 func TestPublishFailure(t *testing.T) {
 	mockCh := &mockChannelForPublish{fail: true}
 
@@ -360,3 +391,34 @@ func TestNewPublisherFailures(t *testing.T) {
 		})
 	}
 }
+
+// mockClosableChannel is a channel that reports whether it is closed.
+type mockClosableChannel struct {
+	mockChannel
+	closed bool
+}
+
+func (m *mockClosableChannel) IsClosed() bool {
+	return m.closed
+}
+
+func TestHealthCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel Channel
+		wantErr error
+	}{
+		{name: "open channel", channel: &mockClosableChannel{}},
+		{name: "closed channel", channel: &mockClosableChannel{closed: true}, wantErr: ErrChannelClosed},
+		{name: "channel without state", channel: &mockChannel{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Publisher{Channel: tt.channel}
+			if err := p.HealthCheck(context.Background()); err != tt.wantErr {
+				t.Errorf("HealthCheck() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package main
+
+import "github.com/beckn-one/beckn-onix/pkg/plugin/implementation/ratelimiter/provider"
+
+// Provider is the exported symbol that the plugin manager will look for.
+var Provider = provider.Provider
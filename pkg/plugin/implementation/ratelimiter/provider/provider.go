@@ -0,0 +1,84 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/ratelimiter"
+)
+
+type provider struct{}
+
+// New creates the rate limiter middleware from the plugin config. The plugin manager
+// uses NewWithCloser instead, so that the Redis connection of redis mode is closed.
+func (p provider) New(ctx context.Context, c map[string]string) (func(http.Handler) http.Handler, error) {
+	mw, _, err := p.NewWithCloser(ctx, c)
+	return mw, err
+}
+
+// NewWithCloser creates the rate limiter middleware from the plugin config and returns
+// the function that closes its Redis connection. rate and burst set the limit of each
+// subscriber, and actions overrides it for individual actions as
+// "search=10:20,confirm=2", where each value is rate[:burst]. addressRate and
+// addressBurst optionally set the limit of each remote address.
+func (p provider) NewWithCloser(ctx context.Context, c map[string]string) (func(http.Handler) http.Handler, func() error, error) {
+	limit, err := parseLimit(c["rate"], c["burst"])
+	if err != nil {
+		return nil, nil, err
+	}
+	config := &ratelimiter.Config{
+		Limit: limit,
+		Mode:  c["mode"],
+		Addr:  c["addr"],
+	}
+	if rate, ok := c["addressRate"]; ok && rate != "" {
+		if config.Address, err = parseLimit(rate, c["addressBurst"]); err != nil {
+			return nil, nil, fmt.Errorf("address: %w", err)
+		}
+	}
+	if actions, ok := c["actions"]; ok && actions != "" {
+		config.Actions = map[string]ratelimiter.Limit{}
+		for _, entry := range strings.Split(actions, ",") {
+			act, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || act == "" {
+				return nil, nil, fmt.Errorf("invalid action limit %q, expected action=rate[:burst]", entry)
+			}
+			rate, burst, _ := strings.Cut(value, ":")
+			l, err := parseLimit(rate, burst)
+			if err != nil {
+				return nil, nil, fmt.Errorf("action %s: %w", act, err)
+			}
+			config.Actions[act] = l
+		}
+	}
+	return ratelimiter.NewRateLimiter(ctx, config)
+}
+
+// parseLimit parses a rate per second and a burst, which defaults to the rate rounded up.
+func parseLimit(rate, burst string) (ratelimiter.Limit, error) {
+	r, err := strconv.ParseFloat(strings.TrimSpace(rate), 64)
+	if err != nil {
+		return ratelimiter.Limit{}, fmt.Errorf("invalid rate %q: %w", rate, err)
+	}
+	l := ratelimiter.Limit{Rate: r, Burst: int(math.Ceil(r))}
+	if burst = strings.TrimSpace(burst); burst != "" {
+		if l.Burst, err = strconv.Atoi(burst); err != nil {
+			return ratelimiter.Limit{}, fmt.Errorf("invalid burst %q: %w", burst, err)
+		}
+	}
+	return l, nil
+}
+
+var Provider = provider{}
+
+func init() {
+	if err := plugin.Register("ratelimiter", &Provider); err != nil {
+		log.Errorf(context.Background(), err, "Failed to register ratelimiter plugin")
+	}
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/ratelimiter"
+)
+
+// TestProviderNewSuccess tests that the middleware is created from valid configs.
+func TestProviderNewSuccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   map[string]string
+		wantCode []int
+	}{
+		{
+			name:     "rate only",
+			config:   map[string]string{"rate": "1"},
+			wantCode: []int{http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:     "rate and burst",
+			config:   map[string]string{"rate": "1", "burst": "2", "mode": "memory"},
+			wantCode: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:     "action limit",
+			config:   map[string]string{"rate": "1", "actions": "search=5:3, confirm=2"},
+			wantCode: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:     "address limit",
+			config:   map[string]string{"rate": "5", "addressRate": "1", "addressBurst": "2"},
+			wantCode: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mw, err := Provider.New(context.Background(), tt.config)
+			require.NoError(t, err)
+			h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			for i, want := range tt.wantCode {
+				req := httptest.NewRequest(http.MethodPost, "/bpp/receiver/search", strings.NewReader(`{"context":{"action":"search"}}`))
+				req.Header.Set("Authorization", `Signature keyId="bap.example.com|key-1|ed25519",signature="sig"`)
+				rec := httptest.NewRecorder()
+				h.ServeHTTP(rec, req)
+				assert.Equal(t, want, rec.Code, "request %d", i+1)
+			}
+		})
+	}
+}
+
+// TestProviderNewFailure tests that invalid configs are rejected.
+func TestProviderNewFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]string
+	}{
+		{name: "missing rate", config: map[string]string{}},
+		{name: "invalid rate", config: map[string]string{"rate": "fast"}},
+		{name: "zero rate", config: map[string]string{"rate": "0"}},
+		{name: "invalid burst", config: map[string]string{"rate": "1", "burst": "many"}},
+		{name: "invalid action entry", config: map[string]string{"rate": "1", "actions": "search"}},
+		{name: "invalid action rate", config: map[string]string{"rate": "1", "actions": "search=x"}},
+		{name: "invalid mode", config: map[string]string{"rate": "1", "mode": "disk"}},
+		{name: "redis without addr", config: map[string]string{"rate": "1", "mode": "redis"}},
+		{name: "invalid address rate", config: map[string]string{"rate": "1", "addressRate": "x"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Provider.New(context.Background(), tt.config)
+			assert.Error(t, err)
+			_, closer, err := Provider.NewWithCloser(context.Background(), tt.config)
+			assert.Error(t, err)
+			assert.Nil(t, closer)
+		})
+	}
+}
+
+// TestParseLimit tests the default burst of a limit.
+func TestParseLimit(t *testing.T) {
+	l, err := parseLimit("2.5", "")
+	require.NoError(t, err)
+	assert.Equal(t, ratelimiter.Limit{Rate: 2.5, Burst: 3}, l)
+}
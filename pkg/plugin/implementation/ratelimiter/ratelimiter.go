@@ -0,0 +1,295 @@
+package ratelimiter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/response"
+)
+
+// Limit is the token bucket of a subscriber: Rate requests per second on average,
+// with bursts of up to Burst requests.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Config represents the configuration for the rate limiter middleware.
+type Config struct {
+	Limit   Limit            // Limit of each subscriber for actions without their own limit
+	Actions map[string]Limit // Limits of each subscriber for individual actions
+	Address Limit            // Limit of each remote address over all subscribers, none if zero
+	Mode    string           // "memory" or "redis", defaults to "memory"
+	Addr    string           // Address of the Redis server in redis mode
+}
+
+const (
+	modeMemory = "memory"
+	modeRedis  = "redis"
+)
+
+// limiter takes tokens from the bucket of a key.
+type limiter interface {
+	// allow takes a token from the bucket of key. If the bucket is empty it reports
+	// how long until a token is available.
+	allow(ctx context.Context, key string, l Limit) (bool, time.Duration, error)
+}
+
+// NewRateLimiter returns a middleware that limits the rate of requests of each
+// subscriber and NACKs requests over the limit with 429 Too Many Requests. The
+// subscriber is named by the request before its signature is validated, so each
+// subscriber has a bucket per remote address, and a sender cannot drain the bucket of
+// another subscriber from a different address. The optional limit of each remote
+// address keeps a sender from escaping its limit by naming other subscribers. In memory
+// mode the limits apply to each adapter instance, and in redis mode to all instances
+// sharing the Redis server. The returned function closes the Redis connection of
+// redis mode and is nil in memory mode.
+func NewRateLimiter(ctx context.Context, cfg *Config) (func(http.Handler) http.Handler, func() error, error) {
+	if err := validateConfig(cfg); err != nil {
+		return nil, nil, err
+	}
+	var l limiter = newMemoryLimiter()
+	var closer func() error
+	if cfg.Mode == modeRedis {
+		rl, c, err := newRedisLimiter(ctx, cfg.Addr)
+		if err != nil {
+			return nil, nil, err
+		}
+		l, closer = rl, c
+	}
+	log.Infof(ctx, "Rate limiter initialized in %s mode with %g requests per second", cfg.Mode, cfg.Limit.Rate)
+	return middleware(cfg, l), closer, nil
+}
+
+// middleware returns the rate limiting middleware using l.
+func middleware(cfg *Config, l limiter) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			act := action(r)
+			addr := remoteAddr(r)
+			if cfg.Address.Rate > 0 && !take(w, r, l, "@"+addr, cfg.Address, addr, act) {
+				return
+			}
+			subID := subscriberID(r, act)
+			if subID == "" {
+				log.Debugf(r.Context(), "No subscriber for request to %s, skipping rate limit", r.URL.Path)
+				next.ServeHTTP(w, r)
+				return
+			}
+			limit, key := cfg.Limit, subID
+			if subID != addr {
+				key += "@" + addr
+			}
+			if al, ok := cfg.Actions[act]; ok {
+				limit, key = al, key+"|"+act
+			}
+			if take(w, r, l, key, limit, subID, act) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// take takes a token from the bucket of key for the request of sender for action and
+// reports whether the request may proceed. If the bucket is empty, the request is
+// NACKed. If the limiter fails, the request may proceed.
+func take(w http.ResponseWriter, r *http.Request, l limiter, key string, limit Limit, sender, act string) bool {
+	ctx := r.Context()
+	ok, wait, err := l.allow(ctx, key, limit)
+	if err != nil {
+		// Do not reject traffic because the limiter is unavailable.
+		log.Errorf(ctx, err, "Rate limiter failed, allowing request of %s", sender)
+		return true
+	}
+	if ok {
+		return true
+	}
+	retryAfter := int(math.Ceil(wait.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	err = model.NewTooManyRequestsErr(fmt.Errorf("rate limit of %g requests per second exceeded by %s for %s", limit.Rate, sender, act))
+	log.Warnf(ctx, "Rejected request: %v", err)
+	response.SendNack(ctx, w, err)
+	return false
+}
+
+// subscriberID returns the subscriber of the request with action, taken from the
+// keyId of the Authorization header, the subscriber ID set on the request context or
+// the sender in the Beckn context, the bpp_id of callbacks and the bap_id of other
+// actions. Requests that name no subscriber are limited by their remote address.
+func subscriberID(r *http.Request, action string) string {
+	if keyID := headerParam(r.Header.Get(model.AuthHeaderSubscriber), "keyId"); keyID != "" {
+		subID, _, _ := strings.Cut(keyID, "|")
+		if subID = strings.TrimSpace(subID); subID != "" {
+			return subID
+		}
+	}
+	if subID, _ := r.Context().Value(model.ContextKeySubscriberID).(string); subID != "" {
+		return subID
+	}
+	if body, err := model.ReadBody(r); err == nil {
+		if bc, err := model.BecknContextFor(r.Context(), body); err == nil {
+			sender := bc.BAPID
+			if strings.HasPrefix(action, "on_") {
+				sender = bc.BPPID
+			}
+			if sender != "" {
+				return sender
+			}
+		}
+	}
+	return remoteAddr(r)
+}
+
+// remoteAddr returns the host of the remote address of the request.
+func remoteAddr(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// headerParam returns the value of the quoted parameter name="<value>" in a Signature header.
+func headerParam(header, name string) string {
+	prefix := name + `="`
+	start := strings.Index(header, prefix)
+	if start == -1 {
+		return ""
+	}
+	start += len(prefix)
+	end := strings.Index(header[start:], `"`)
+	if end == -1 {
+		return ""
+	}
+	return strings.TrimSpace(header[start : start+end])
+}
+
+// action returns the Beckn action of the request, or the last segment of its path
+// if the body has none.
+func action(r *http.Request) string {
+	body, err := model.ReadBody(r)
+	if err == nil {
+		if bc, err := model.BecknContextFor(r.Context(), body); err == nil && bc.Action != "" {
+			return bc.Action
+		}
+	}
+	return path.Base(r.URL.Path)
+}
+
+func validateConfig(cfg *Config) error {
+	if cfg == nil {
+		return errors.New("config cannot be nil")
+	}
+	if err := validateLimit(cfg.Limit); err != nil {
+		return err
+	}
+	for act, l := range cfg.Actions {
+		if err := validateLimit(l); err != nil {
+			return fmt.Errorf("action %s: %w", act, err)
+		}
+	}
+	if cfg.Address != (Limit{}) {
+		if err := validateLimit(cfg.Address); err != nil {
+			return fmt.Errorf("address: %w", err)
+		}
+	}
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = modeMemory
+	case modeMemory:
+	case modeRedis:
+		if cfg.Addr == "" {
+			return errors.New("addr is required in redis mode")
+		}
+	default:
+		return fmt.Errorf("mode must be either '%s' or '%s'", modeMemory, modeRedis)
+	}
+	return nil
+}
+
+func validateLimit(l Limit) error {
+	if l.Rate <= 0 {
+		return errors.New("rate must be positive")
+	}
+	if l.Burst < 1 {
+		return errors.New("burst must be at least 1")
+	}
+	return nil
+}
+
+// bucket is the token bucket of one key.
+type bucket struct {
+	limit  Limit
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens accrued since the last request, up to the burst.
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	b.last = now
+}
+
+// memoryLimiter keeps the token buckets in memory, so limits apply per adapter instance.
+type memoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// sweepInterval is how often buckets that have refilled are removed.
+const sweepInterval = time.Minute
+
+// newMemoryLimiter returns an in-memory limiter.
+func newMemoryLimiter() *memoryLimiter {
+	return &memoryLimiter{buckets: map[string]*bucket{}, now: time.Now}
+}
+
+// allow takes a token from the bucket of key.
+func (m *memoryLimiter) allow(ctx context.Context, key string, l Limit) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		m.buckets[key] = b
+	}
+	b.limit = l
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / l.Rate * float64(time.Second)), nil
+}
+
+// sweep removes the buckets that have refilled, as they are equivalent to new ones.
+// It runs at most once per sweepInterval.
+func (m *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if b.refill(now); b.tokens >= float64(b.limit.Burst) {
+			delete(m.buckets, key)
+		}
+	}
+}
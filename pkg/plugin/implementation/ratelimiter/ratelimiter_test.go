@@ -0,0 +1,334 @@
+package ratelimiter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/cache"
+)
+
+// fakeClock is a settable clock for the memory limiter.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func newTestLimiter() (*memoryLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := newMemoryLimiter()
+	m.now = clock.Now
+	return m, clock
+}
+
+// errLimiter is a limiter that always fails.
+type errLimiter struct{}
+
+func (errLimiter) allow(ctx context.Context, key string, l Limit) (bool, time.Duration, error) {
+	return false, 0, errors.New("limiter unavailable")
+}
+
+func signedRequest(subID, action string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/bpp/receiver/"+action, strings.NewReader(`{"context":{"action":"`+action+`"}}`))
+	if subID != "" {
+		req.Header.Set(model.AuthHeaderSubscriber, `Signature keyId="`+subID+`|key-1|ed25519",algorithm="ed25519",signature="sig"`)
+	}
+	return req
+}
+
+// TestMemoryLimiter tests the token bucket of the memory limiter.
+func TestMemoryLimiter(t *testing.T) {
+	m, clock := newTestLimiter()
+	ctx := context.Background()
+	l := Limit{Rate: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		ok, _, err := m.allow(ctx, "bap", l)
+		require.NoError(t, err)
+		assert.True(t, ok, "request %d within burst", i+1)
+	}
+	ok, wait, _ := m.allow(ctx, "bap", l)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Other keys have their own bucket.
+	ok, _, _ = m.allow(ctx, "bpp", l)
+	assert.True(t, ok)
+
+	// Tokens refill at the rate.
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	ok, _, _ = m.allow(ctx, "bap", l)
+	assert.True(t, ok)
+	ok, _, _ = m.allow(ctx, "bap", l)
+	assert.False(t, ok)
+}
+
+// TestMemoryLimiterSweep tests that only refilled buckets are removed.
+func TestMemoryLimiterSweep(t *testing.T) {
+	m, clock := newTestLimiter()
+	ctx := context.Background()
+	m.allow(ctx, "fast", Limit{Rate: 10, Burst: 1})
+	m.allow(ctx, "slow", Limit{Rate: 0.001, Burst: 1})
+
+	clock.now = clock.now.Add(sweepInterval)
+	m.allow(ctx, "other", Limit{Rate: 1, Burst: 1})
+	assert.NotContains(t, m.buckets, "fast")
+	assert.Contains(t, m.buckets, "slow")
+
+	// The slow bucket is still empty.
+	ok, _, _ := m.allow(ctx, "slow", Limit{Rate: 0.001, Burst: 1})
+	assert.False(t, ok)
+}
+
+// TestRateLimiter tests that requests over the limit are NACKed with 429 Too Many Requests.
+func TestRateLimiter(t *testing.T) {
+	m, _ := newTestLimiter()
+	cfg := &Config{
+		Limit:   Limit{Rate: 0.5, Burst: 1},
+		Actions: map[string]Limit{"search": {Rate: 1, Burst: 2}},
+	}
+	require.NoError(t, validateConfig(cfg))
+	h := middleware(cfg, m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusOK, serve(signedRequest("bap.example.com", "confirm")).Code)
+	rec := serve(signedRequest("bap.example.com", "confirm"))
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	var resp model.Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, model.StatusNACK, resp.Message.Ack.Status)
+	require.NotNil(t, resp.Message.Error)
+	assert.Equal(t, "Too Many Requests", resp.Message.Error.Code)
+
+	// Actions with their own limit use a separate bucket.
+	assert.Equal(t, http.StatusOK, serve(signedRequest("bap.example.com", "search")).Code)
+	assert.Equal(t, http.StatusOK, serve(signedRequest("bap.example.com", "search")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(signedRequest("bap.example.com", "search")).Code)
+
+	// Other subscribers are not affected.
+	assert.Equal(t, http.StatusOK, serve(signedRequest("other.example.com", "confirm")).Code)
+
+	// The subscriber may come from the request context.
+	req := signedRequest("", "confirm")
+	req = req.WithContext(context.WithValue(req.Context(), model.ContextKeySubscriberID, "bpp.example.com"))
+	assert.Equal(t, http.StatusOK, serve(req).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(req).Code)
+
+	// Unsigned requests are limited by the sender in their Beckn context.
+	unsigned := func(remoteAddr string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/bpp/receiver/confirm", strings.NewReader(`{"context":{"action":"confirm","bap_id":"unsigned.example.com"}}`))
+		req.RemoteAddr = remoteAddr
+		return req
+	}
+	assert.Equal(t, http.StatusOK, serve(unsigned("192.0.2.1:1234")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(unsigned("192.0.2.1:5678")).Code)
+
+	// A sender at another address naming the same subscriber has its own bucket.
+	assert.Equal(t, http.StatusOK, serve(unsigned("198.51.100.1:1234")).Code)
+	spoofed := signedRequest("bap.example.com", "confirm")
+	spoofed.RemoteAddr = "198.51.100.1:1234"
+	assert.Equal(t, http.StatusOK, serve(spoofed).Code)
+
+	// Requests that name no subscriber are limited by their remote address.
+	assert.Equal(t, http.StatusOK, serve(signedRequest("", "confirm")).Code)
+	assert.Equal(t, http.StatusTooManyRequests, serve(signedRequest("", "confirm")).Code)
+}
+
+// TestRateLimiterAddress tests that the limit of a remote address applies over all
+// the subscribers its requests name.
+func TestRateLimiterAddress(t *testing.T) {
+	m, _ := newTestLimiter()
+	cfg := &Config{Limit: Limit{Rate: 1, Burst: 1}, Address: Limit{Rate: 1, Burst: 2}}
+	require.NoError(t, validateConfig(cfg))
+	h := middleware(cfg, m)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(subID, remoteAddr string) int {
+		req := signedRequest(subID, "search")
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve("a.example.com", "192.0.2.1:1234"))
+	assert.Equal(t, http.StatusOK, serve("b.example.com", "192.0.2.1:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("c.example.com", "192.0.2.1:1234"))
+
+	// Other addresses are not affected.
+	assert.Equal(t, http.StatusOK, serve("c.example.com", "192.0.2.2:1234"))
+}
+
+// TestRateLimiterFailOpen tests that requests are allowed when the limiter fails.
+func TestRateLimiterFailOpen(t *testing.T) {
+	cfg := &Config{Limit: Limit{Rate: 1, Burst: 1}}
+	h := middleware(cfg, errLimiter{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, signedRequest("bap.example.com", "search"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+// TestSubscriberID tests extracting the subscriber from requests.
+func TestSubscriberID(t *testing.T) {
+	body := `{"context":{"bap_id":"bap.example.com","bpp_id":"bpp.example.com"}}`
+	tests := []struct {
+		name   string
+		header string
+		ctxSub string
+		action string
+		body   string
+		want   string
+	}{
+		{name: "keyId", header: `Signature keyId="bap.example.com|key-1|ed25519",signature="sig"`, want: "bap.example.com"},
+		{name: "keyId over context", header: `Signature keyId="bap.example.com|key-1|ed25519"`, ctxSub: "ctx.example.com", body: body, want: "bap.example.com"},
+		{name: "context", ctxSub: "ctx.example.com", body: body, want: "ctx.example.com"},
+		{name: "empty keyId", header: `Signature keyId=""`, ctxSub: "ctx.example.com", want: "ctx.example.com"},
+		{name: "bap_id of request", action: "search", body: body, want: "bap.example.com"},
+		{name: "bpp_id of callback", action: "on_search", body: body, want: "bpp.example.com"},
+		{name: "remote address", action: "search", body: `{"context":{}}`, want: "192.0.2.1"},
+		{name: "none", want: "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.header != "" {
+				req.Header.Set(model.AuthHeaderSubscriber, tt.header)
+			}
+			if tt.ctxSub != "" {
+				req = req.WithContext(context.WithValue(req.Context(), model.ContextKeySubscriberID, tt.ctxSub))
+			}
+			assert.Equal(t, tt.want, subscriberID(req, tt.action))
+		})
+	}
+}
+
+// TestValidateConfig tests that invalid configs are rejected.
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr string
+	}{
+		{name: "nil config", wantErr: "config cannot be nil"},
+		{name: "zero rate", cfg: &Config{Limit: Limit{Burst: 1}}, wantErr: "rate must be positive"},
+		{name: "zero burst", cfg: &Config{Limit: Limit{Rate: 1}}, wantErr: "burst must be at least 1"},
+		{name: "invalid action", cfg: &Config{Limit: Limit{Rate: 1, Burst: 1}, Actions: map[string]Limit{"search": {Rate: -1, Burst: 1}}}, wantErr: "action search: rate must be positive"},
+		{name: "invalid mode", cfg: &Config{Limit: Limit{Rate: 1, Burst: 1}, Mode: "disk"}, wantErr: "mode must be either"},
+		{name: "redis without addr", cfg: &Config{Limit: Limit{Rate: 1, Burst: 1}, Mode: modeRedis}, wantErr: "addr is required"},
+		{name: "invalid address limit", cfg: &Config{Limit: Limit{Rate: 1, Burst: 1}, Address: Limit{Rate: 1}}, wantErr: "address: burst must be at least 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(tt.cfg)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	cfg := &Config{Limit: Limit{Rate: 1, Burst: 1}}
+	require.NoError(t, validateConfig(cfg))
+	assert.Equal(t, modeMemory, cfg.Mode)
+}
+
+// fakeScripter returns a fixed result for token bucket scripts.
+type fakeScripter struct {
+	redis.Scripter
+	result []interface{}
+	err    error
+	keys   []string
+}
+
+func (f *fakeScripter) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	f.keys = keys
+	return redis.NewCmdResult(f.result, f.err)
+}
+
+// fakeRedisClient is a Redis client for the cache plugin that runs scripts with fakeScripter.
+type fakeRedisClient struct {
+	cache.RedisClient
+	*fakeScripter
+	closed bool
+}
+
+func (c *fakeRedisClient) Ping(ctx context.Context) *redis.StatusCmd {
+	return redis.NewStatusResult("PONG", nil)
+}
+
+func (c *fakeRedisClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+// TestNewRateLimiterRedis tests that the Redis connection of redis mode is closed by
+// the returned function.
+func TestNewRateLimiterRedis(t *testing.T) {
+	original := cache.RedisClientFunc
+	defer func() { cache.RedisClientFunc = original }()
+	client := &fakeRedisClient{fakeScripter: &fakeScripter{result: []interface{}{int64(1), int64(0)}}}
+	cache.RedisClientFunc = func(cfg *cache.Config) cache.RedisClient { return client }
+
+	mw, closer, err := NewRateLimiter(context.Background(), &Config{Limit: Limit{Rate: 1, Burst: 1}, Mode: modeRedis, Addr: "redis:6379"})
+	require.NoError(t, err)
+	require.NotNil(t, closer)
+	rec := httptest.NewRecorder()
+	mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, signedRequest("bap.example.com", "search"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{keyPrefix + "bap.example.com@192.0.2.1"}, client.keys)
+
+	require.NoError(t, closer())
+	assert.True(t, client.closed)
+
+	_, closer, err = NewRateLimiter(context.Background(), &Config{Limit: Limit{Rate: 1, Burst: 1}})
+	require.NoError(t, err)
+	assert.Nil(t, closer, "memory mode has no connection to close")
+}
+
+// TestRedisLimiter tests interpreting the results of the token bucket script.
+func TestRedisLimiter(t *testing.T) {
+	tests := []struct {
+		name     string
+		client   *fakeScripter
+		wantOK   bool
+		wantWait time.Duration
+		wantErr  bool
+	}{
+		{name: "allowed", client: &fakeScripter{result: []interface{}{int64(1), int64(0)}}, wantOK: true},
+		{name: "rejected", client: &fakeScripter{result: []interface{}{int64(0), int64(1500)}}, wantWait: 1500 * time.Millisecond},
+		{name: "redis error", client: &fakeScripter{err: errors.New("connection refused")}, wantErr: true},
+		{name: "unexpected result", client: &fakeScripter{result: []interface{}{int64(1)}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &redisLimiter{client: tt.client}
+			ok, wait, err := r.allow(context.Background(), "bap.example.com", Limit{Rate: 1, Burst: 1})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantWait, wait)
+			assert.Equal(t, []string{keyPrefix + "bap.example.com"}, tt.client.keys)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimiter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/cache"
+)
+
+// keyPrefix namespaces the token buckets in Redis.
+const keyPrefix = "ratelimit:"
+
+// tokenBucket atomically refills and takes a token from the bucket stored in the
+// hash KEYS[1], using the clock of the Redis server so that all adapter instances
+// agree. ARGV holds the rate per second and the burst. It returns whether a token
+// was taken and otherwise the milliseconds until one is available.
+var tokenBucket = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or burst
+local ts = tonumber(state[2]) or now
+tokens = math.min(burst, tokens + (now - ts) / 1000 * rate)
+local allowed, wait = 0, 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  wait = math.ceil((1 - tokens) / rate * 1000)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst / rate * 1000) + 1000)
+return {allowed, wait}
+`)
+
+// redisLimiter keeps the token buckets in Redis, so limits apply across adapter instances.
+type redisLimiter struct {
+	client redis.Scripter
+}
+
+// newRedisLimiter connects to Redis with the client of the cache plugin. The returned
+// function closes the connection.
+func newRedisLimiter(ctx context.Context, addr string) (*redisLimiter, func() error, error) {
+	c, closer, err := cache.New(ctx, &cache.Config{Addr: addr})
+	if err != nil {
+		return nil, nil, err
+	}
+	client, ok := c.Client.(redis.Scripter)
+	if !ok {
+		closer()
+		return nil, nil, fmt.Errorf("redis client %T does not support scripts", c.Client)
+	}
+	return &redisLimiter{client: client}, closer, nil
+}
+
+// allow takes a token from the bucket of key in Redis.
+func (r *redisLimiter) allow(ctx context.Context, key string, l Limit) (bool, time.Duration, error) {
+	res, err := tokenBucket.Run(ctx, r.client, []string{keyPrefix + key}, l.Rate, l.Burst).Int64Slice()
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to take token for %s: %w", key, err)
+	}
+	if len(res) != 2 {
+		return false, 0, fmt.Errorf("unexpected token bucket result %v", res)
+	}
+	return res[0] == 1, time.Duration(res[1]) * time.Millisecond, nil
+}
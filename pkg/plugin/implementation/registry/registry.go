@@ -11,7 +11,10 @@ import (
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
+	"github.com/beckn-one/beckn-onix/pkg/tracing"
 	"github.com/hashicorp/go-retryablehttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Config holds configuration parameters for the registry client.
@@ -113,6 +116,30 @@ func (c *RegistryClient) Subscribe(ctx context.Context, subscription *model.Subs
 
 // Lookup calls the /lookup endpoint with retry and returns a slice of Subscription.
 func (c *RegistryClient) Lookup(ctx context.Context, subscription *model.Subscription) ([]model.Subscription, error) {
+	ctx, span := tracing.Start(ctx, "registry.Lookup",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("onix.subscriber_id", subscription.SubscriberID)))
+	results, err := c.lookup(ctx, subscription)
+	tracing.End(span, err)
+	return results, err
+}
+
+// HealthCheck verifies that the registry is reachable. Any HTTP response counts as reachable.
+func (c *RegistryClient) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.URL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := c.client.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("registry unreachable: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// lookup performs the registry lookup for Lookup.
+func (c *RegistryClient) lookup(ctx context.Context, subscription *model.Subscription) ([]model.Subscription, error) {
 	lookupURL := fmt.Sprintf("%s/lookup", c.config.URL)
 
 	jsonData, err := json.Marshal(subscription)
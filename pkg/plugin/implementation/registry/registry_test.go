@@ -202,3 +202,23 @@ func TestRegistryClient_Lookup(t *testing.T) {
 		}
 	})
 }
+
+func TestRegistryClient_HealthCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	client, closer, err := New(context.Background(), &Config{URL: server.URL, RetryMax: 1})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer closer()
+
+	if err := client.HealthCheck(context.Background()); err != nil {
+		t.Errorf("HealthCheck() error = %v, want nil for a reachable registry", err)
+	}
+
+	server.Close()
+	if err := client.HealthCheck(context.Background()); err == nil {
+		t.Error("HealthCheck() error = nil, want error for an unreachable registry")
+	}
+}
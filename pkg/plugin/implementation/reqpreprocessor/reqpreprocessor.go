@@ -1,12 +1,9 @@
 package reqpreprocessor
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
@@ -29,42 +26,42 @@ func NewPreProcessor(cfg *Config) (func(http.Handler) http.Handler, error) {
 	}
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			body, err := io.ReadAll(r.Body)
+			body, err := model.ReadBody(r)
 			if err != nil {
 				http.Error(w, "Failed to read request body", http.StatusBadRequest)
 				return
 			}
-			var req map[string]interface{}
 			ctx := r.Context()
-			if err := json.Unmarshal(body, &req); err != nil {
-				http.Error(w, "Failed to decode request body", http.StatusBadRequest)
-				return
-			}
-
 			// Extract context from request.
-			reqContext, ok := req["context"].(map[string]interface{})
-			if !ok {
+			reqContext, err := model.BecknContextFor(ctx, body)
+			if errors.Is(err, model.ErrNoBecknContext) {
 				http.Error(w, fmt.Sprintf("%s field not found or invalid.", contextKey), http.StatusBadRequest)
 				return
 			}
-			var subID any
+			if err != nil {
+				http.Error(w, "Failed to decode request body", http.StatusBadRequest)
+				return
+			}
+			// Later stages of the request reuse the parsed context.
+			ctx = model.WithBecknContext(ctx, body, reqContext)
+
+			var subID string
 			switch cfg.Role {
 			case "bap":
-				subID = reqContext["bap_id"]
+				subID = reqContext.BAPID
 			case "bpp":
-				subID = reqContext["bpp_id"]
+				subID = reqContext.BPPID
 			}
-			if subID != nil {
+			if subID != "" {
 				log.Debugf(ctx, "adding subscriberId to request:%s, %v", model.ContextKeySubscriberID, subID)
 				ctx = context.WithValue(ctx, model.ContextKeySubscriberID, subID)
 			}
 			for _, key := range cfg.ContextKeys {
 				ctxKey, _ := model.ParseContextKey(key)
-				if v, ok := reqContext[key]; ok {
+				if v := contextValue(reqContext, ctxKey); v != "" {
 					ctx = context.WithValue(ctx, ctxKey, v)
 				}
 			}
-			r.Body = io.NopCloser(bytes.NewBuffer(body))
 			r.ContentLength = int64(len(body))
 			r = r.WithContext(ctx)
 			next.ServeHTTP(w, r)
@@ -72,6 +69,18 @@ func NewPreProcessor(cfg *Config) (func(http.Handler) http.Handler, error) {
 	}, nil
 }
 
+// contextValue returns the value of a context key in the Beckn context, or "" for
+// keys that are not part of it.
+func contextValue(bc *model.BecknContext, key model.ContextKey) string {
+	switch key {
+	case model.ContextKeyTxnID:
+		return bc.TransactionID
+	case model.ContextKeyMsgID:
+		return bc.MessageID
+	}
+	return ""
+}
+
 func validateConfig(cfg *Config) error {
 	if cfg == nil {
 		return errors.New("config cannot be nil")
@@ -0,0 +1,175 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beckn-one/beckn-onix/pkg/model"
+)
+
+// condition matches a field of the request body against a set of values.
+type condition struct {
+	Path   string   `yaml:"path"`   // JSONPath of the field, e.g. $.context.location.city.code
+	Values []string `yaml:"values"` // The field must equal one of these values
+}
+
+// pathSegment is a single step of a parsed JSONPath: an object key or an array index.
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// predicate is a compiled condition.
+type predicate struct {
+	path   []pathSegment
+	values map[string]bool
+}
+
+// matchRoute is the route of a rule that only applies when all of its predicates match.
+type matchRoute struct {
+	predicates []predicate
+	route      *model.Route
+}
+
+// compileConditions parses the paths of the conditions of a rule.
+func compileConditions(conditions []condition) ([]predicate, error) {
+	predicates := make([]predicate, 0, len(conditions))
+	for _, c := range conditions {
+		if len(c.Values) == 0 {
+			return nil, fmt.Errorf("invalid rule: values are required for match path %q", c.Path)
+		}
+		segments, err := parsePath(c.Path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rule: match path %q: %w", c.Path, err)
+		}
+		values := make(map[string]bool, len(c.Values))
+		for _, v := range c.Values {
+			values[v] = true
+		}
+		predicates = append(predicates, predicate{path: segments, values: values})
+	}
+	return predicates, nil
+}
+
+// parsePath parses a JSONPath made of object keys and array indexes, such as
+// $.message.order.items[0].id or $['context']['bpp_id']. The leading $ is optional.
+func parsePath(p string) ([]pathSegment, error) {
+	s := strings.TrimSpace(p)
+	if strings.HasPrefix(s, "$") {
+		s = s[1:]
+	} else {
+		s = "." + s
+	}
+	var segments []pathSegment
+	for s != "" {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end < 0 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty field name")
+			}
+			segments = append(segments, pathSegment{key: s[:end]})
+			s = s[end:]
+		case '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [")
+			}
+			inner := s[1:end]
+			s = s[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, pathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid array index %q", inner)
+			}
+			segments = append(segments, pathSegment{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("unexpected character %q", s[0])
+		}
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("path selects no field")
+	}
+	return segments, nil
+}
+
+// decodeBody decodes the request body for evaluating predicates, keeping numbers as written.
+func decodeBody(body []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("error parsing request body: %w", err)
+	}
+	return doc, nil
+}
+
+// firstMatch returns the route of the first candidate whose predicates all match
+// the request body, or nil if none match.
+func firstMatch(candidates []matchRoute, body []byte) (*model.Route, error) {
+	doc, err := decodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range candidates {
+		if matchesAll(c.predicates, doc) {
+			return c.route, nil
+		}
+	}
+	return nil, nil
+}
+
+// matchesAll reports whether every predicate matches the document.
+func matchesAll(predicates []predicate, doc any) bool {
+	for _, p := range predicates {
+		v, ok := lookupPath(doc, p.path)
+		if !ok || !p.values[v] {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupPath returns the scalar at path in doc as a string. It reports false if the
+// path does not exist or does not lead to a string, number or boolean.
+func lookupPath(doc any, path []pathSegment) (string, bool) {
+	cur := doc
+	for _, seg := range path {
+		if seg.isIndex {
+			arr, ok := cur.([]any)
+			if !ok || seg.index >= len(arr) {
+				return "", false
+			}
+			cur = arr[seg.index]
+			continue
+		}
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if cur, ok = obj[seg.key]; !ok {
+			return "", false
+		}
+	}
+	switch v := cur.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,87 @@
+package router
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParsePath tests parsing of JSONPath expressions.
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []pathSegment
+	}{
+		{path: "$.context.bpp_id", want: []pathSegment{{key: "context"}, {key: "bpp_id"}}},
+		{path: "context.bpp_id", want: []pathSegment{{key: "context"}, {key: "bpp_id"}}},
+		{path: "$.message.order.items[1].id", want: []pathSegment{{key: "message"}, {key: "order"}, {key: "items"}, {index: 1, isIndex: true}, {key: "id"}}},
+		{path: "$['context'][\"location.city\"]", want: []pathSegment{{key: "context"}, {key: "location.city"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parsePath(tt.path)
+			if err != nil {
+				t.Fatalf("parsePath() err = %v, want nil", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePath() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParsePathFailure tests that malformed JSONPath expressions are rejected.
+func TestParsePathFailure(t *testing.T) {
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path: "$", wantErr: "path selects no field"},
+		{path: "", wantErr: "empty field name"},
+		{path: "$.context..bpp_id", wantErr: "empty field name"},
+		{path: "$.items[0", wantErr: "unterminated ["},
+		{path: "$.items[-1]", wantErr: "invalid array index"},
+		{path: "$context", wantErr: "unexpected character"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := parsePath(tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parsePath() err = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestLookupPath tests resolving JSONPath expressions against a request body.
+func TestLookupPath(t *testing.T) {
+	doc, err := decodeBody([]byte(`{"context": {"ttl": 30, "test": true}, "message": {"items": [{"id": "i1"}, {"id": "i2"}], "order": {}}}`))
+	if err != nil {
+		t.Fatalf("decodeBody() err = %v, want nil", err)
+	}
+	tests := []struct {
+		path   string
+		want   string
+		wantOK bool
+	}{
+		{path: "$.message.items[1].id", want: "i2", wantOK: true},
+		{path: "$.context.ttl", want: "30", wantOK: true},
+		{path: "$.context.test", want: "true", wantOK: true},
+		{path: "$.message.items[2].id"},
+		{path: "$.message.order"},
+		{path: "$.message.missing"},
+		{path: "$.context.ttl.value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			path, err := parsePath(tt.path)
+			if err != nil {
+				t.Fatalf("parsePath() err = %v, want nil", err)
+			}
+			got, ok := lookupPath(doc, path)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("lookupPath() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package router
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+)
+
+// anyEndpoint is the endpoint of a rule that applies to every endpoint.
+const anyEndpoint = "*"
+
+// isDomainPattern reports whether a rule domain is a glob such as ONDC:RET*.
+func isDomainPattern(domain string) bool {
+	return strings.ContainsAny(domain, "*?[")
+}
+
+// patternRule is a rule for one endpoint whose domain, version or endpoint is a pattern.
+type patternRule struct {
+	rule       routingRule
+	endpoint   string             // The endpoint, or anyEndpoint
+	constraint *versionConstraint // nil when the version is exact
+	predicates []predicate
+	split      *trafficSplit // nil unless the rule has weighted targets
+	index      int           // Position of the rule in the routing config
+}
+
+// newPatternRule compiles the pattern rule of a routing rule for an endpoint.
+func newPatternRule(rule routingRule, endpoint string, predicates []predicate, index int) (patternRule, error) {
+	pr := patternRule{rule: rule, endpoint: endpoint, predicates: predicates, index: index}
+	if isVersionConstraint(rule.Version) {
+		c, err := parseVersionConstraint(rule.Version)
+		if err != nil {
+			return pr, fmt.Errorf("invalid rule: version %q: %w", rule.Version, err)
+		}
+		pr.constraint = c
+	}
+	return pr, nil
+}
+
+// selects reports whether the rule applies to a domain, version and endpoint.
+func (pr patternRule) selects(domain, version, endpoint string) bool {
+	if pr.endpoint != anyEndpoint && pr.endpoint != endpoint {
+		return false
+	}
+	if pr.constraint != nil {
+		if !pr.constraint.matches(version) {
+			return false
+		}
+	} else if pr.rule.Version != version {
+		return false
+	}
+	if isDomainPattern(pr.rule.Domain) {
+		ok, _ := path.Match(pr.rule.Domain, domain)
+		return ok
+	}
+	return pr.rule.Domain == domain
+}
+
+// specificity ranks how narrowly a rule selects requests by domain, then version,
+// then endpoint. An exact domain outranks any glob, and globs with more literal
+// characters outrank those with fewer. An exact version outranks a constraint,
+// which outranks *. An exact endpoint outranks *.
+func specificity(domain, version, endpoint string) [3]int {
+	var s [3]int
+	s[0] = math.MaxInt
+	if isDomainPattern(domain) {
+		s[0] = len(strings.NewReplacer("*", "", "?", "").Replace(domain))
+	}
+	switch {
+	case !isVersionConstraint(version):
+		s[1] = 2
+	case strings.TrimSpace(version) != "*":
+		s[1] = 1
+	}
+	if endpoint != anyEndpoint {
+		s[2] = 1
+	}
+	return s
+}
+
+// compareSpecificity returns a positive number if a is more specific than b,
+// a negative number if it is less specific and zero if they are equally specific.
+func compareSpecificity(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] > b[i] {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+// sortPatternRules orders pattern rules from most to least specific. Among equally
+// specific rules, rules with conditions come first in file order, and rules without
+// conditions follow with the last one first, as later rules replace earlier ones.
+func sortPatternRules(rules []patternRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		a, b := rules[i], rules[j]
+		n := compareSpecificity(
+			specificity(a.rule.Domain, a.rule.Version, a.endpoint),
+			specificity(b.rule.Domain, b.rule.Version, b.endpoint))
+		if n != 0 {
+			return n > 0
+		}
+		ca, cb := len(a.predicates) > 0, len(b.predicates) > 0
+		if ca != cb {
+			return ca
+		}
+		if ca {
+			return a.index < b.index
+		}
+		return a.index > b.index
+	})
+}
+
+// matchPatterns returns the first pattern rule that applies to the request. It also
+// reports whether any rule selected the domain, version and endpoint, even if its
+// conditions did not match.
+func matchPatterns(rules []patternRule, domain, version, endpoint string, body []byte) (*patternRule, bool, error) {
+	var doc any
+	selected := false
+	for _, pr := range rules {
+		if !pr.selects(domain, version, endpoint) {
+			continue
+		}
+		selected = true
+		if len(pr.predicates) > 0 {
+			if doc == nil {
+				var err error
+				if doc, err = decodeBody(body); err != nil {
+					return nil, true, err
+				}
+			}
+			if !matchesAll(pr.predicates, doc) {
+				continue
+			}
+		}
+		return &pr, true, nil
+	}
+	return nil, selected, nil
+}
+
+// domainsOverlap reports whether two rule domains, each exact or a glob, can match
+// the same request domain. Two globs are compared by their literal prefix and
+// suffix, so they may be reported as overlapping when no domain matches both.
+func domainsOverlap(a, b string) bool {
+	pa, pb := isDomainPattern(a), isDomainPattern(b)
+	switch {
+	case !pa && !pb:
+		return a == b
+	case !pb:
+		ok, _ := path.Match(a, b)
+		return ok
+	case !pa:
+		ok, _ := path.Match(b, a)
+		return ok
+	}
+	prefixA, suffixA := literalEnds(a)
+	prefixB, suffixB := literalEnds(b)
+	return (strings.HasPrefix(prefixA, prefixB) || strings.HasPrefix(prefixB, prefixA)) &&
+		(strings.HasSuffix(suffixA, suffixB) || strings.HasSuffix(suffixB, suffixA))
+}
+
+// literalEnds returns the literal text before the first and after the last wildcard of a glob.
+func literalEnds(glob string) (string, string) {
+	first := strings.IndexAny(glob, "*?[")
+	last := strings.LastIndexAny(glob, "*?]")
+	return glob[:first], glob[last+1:]
+}
+
+// overlaps returns a report of the rules without conditions that can match the same
+// request, naming the rule that takes precedence. Rules are numbered from 1 in file order.
+func overlaps(rules []routingRule) []string {
+	var report []string
+	for i := range rules {
+		for j := i + 1; j < len(rules); j++ {
+			a, b := rules[i], rules[j]
+			if len(a.Match) > 0 || len(b.Match) > 0 {
+				continue
+			}
+			if !domainsOverlap(a.Domain, b.Domain) || !versionsOverlap(a.Version, b.Version) {
+				continue
+			}
+			for _, ea := range a.Endpoints {
+				for _, eb := range b.Endpoints {
+					if ea != eb && ea != anyEndpoint && eb != anyEndpoint {
+						continue
+					}
+					winner := j + 1
+					if precedes(a, ea, b, eb) {
+						winner = i + 1
+					}
+					report = append(report, fmt.Sprintf(
+						"routing rules #%d (domain %s, version %s, endpoint %s) and #%d (domain %s, version %s, endpoint %s) overlap, #%d takes precedence",
+						i+1, a.Domain, a.Version, ea, j+1, b.Domain, b.Version, eb, winner))
+				}
+			}
+		}
+	}
+	return report
+}
+
+// precedes reports whether the earlier rule a takes precedence over the later rule b
+// for the requests they both match.
+func precedes(a routingRule, ea string, b routingRule, eb string) bool {
+	return compareSpecificity(specificity(a.Domain, a.Version, ea), specificity(b.Domain, b.Version, eb)) > 0
+}
@@ -0,0 +1,73 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestDomainsOverlap tests detecting rule domains that match the same request domain.
+func TestDomainsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "ONDC:RET10", b: "ONDC:RET10", want: true},
+		{a: "ONDC:RET10", b: "ONDC:RET11", want: false},
+		{a: "ONDC:RET*", b: "ONDC:RET10", want: true},
+		{a: "ONDC:TRV10", b: "ONDC:RET*", want: false},
+		{a: "ONDC:RET*", b: "ONDC:RET1?", want: true},
+		{a: "ONDC:RET*", b: "ONDC:TRV*", want: false},
+		{a: "*:RET10", b: "ONDC:*", want: true},
+		{a: "*:RET10", b: "*:TRV10", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := domainsOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("domainsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOverlaps tests the report of overlapping routing rules.
+func TestOverlaps(t *testing.T) {
+	rules := []routingRule{
+		{Domain: "ONDC:RET*", Version: ">=1.1.0 <2.0.0", Endpoints: []string{"*"}},
+		{Domain: "ONDC:RET10", Version: "1.2.0", Endpoints: []string{"search", "select"}},
+		{Domain: "ONDC:RET10", Version: "2.0.0", Endpoints: []string{"search"}},
+		{Domain: "ONDC:RET10", Version: "1.2.0", Endpoints: []string{"select"}},
+		{Domain: "ONDC:RET10", Version: "1.2.0", Endpoints: []string{"search"}, Match: []condition{{Path: "$.context.bpp_id", Values: []string{"bpp1"}}}},
+		{Domain: "ONDC:TRV10", Version: "1.2.0", Endpoints: []string{"search"}},
+	}
+	want := []string{
+		"routing rules #1 (domain ONDC:RET*, version >=1.1.0 <2.0.0, endpoint *) and #2 (domain ONDC:RET10, version 1.2.0, endpoint search) overlap, #2 takes precedence",
+		"routing rules #1 (domain ONDC:RET*, version >=1.1.0 <2.0.0, endpoint *) and #2 (domain ONDC:RET10, version 1.2.0, endpoint select) overlap, #2 takes precedence",
+		"routing rules #1 (domain ONDC:RET*, version >=1.1.0 <2.0.0, endpoint *) and #4 (domain ONDC:RET10, version 1.2.0, endpoint select) overlap, #4 takes precedence",
+		"routing rules #2 (domain ONDC:RET10, version 1.2.0, endpoint select) and #4 (domain ONDC:RET10, version 1.2.0, endpoint select) overlap, #4 takes precedence",
+	}
+	if got := overlaps(rules); !reflect.DeepEqual(got, want) {
+		t.Errorf("overlaps() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+// TestSortPatternRules tests the precedence of pattern rules.
+func TestSortPatternRules(t *testing.T) {
+	cond := []predicate{{}}
+	rules := []patternRule{
+		{rule: routingRule{Domain: "ONDC:*", Version: "1.0.0"}, endpoint: "search", index: 0},
+		{rule: routingRule{Domain: "ONDC:RET*", Version: "*"}, endpoint: "search", index: 1},
+		{rule: routingRule{Domain: "ONDC:RET*", Version: ">=1.0.0"}, endpoint: "*", index: 2},
+		{rule: routingRule{Domain: "ONDC:RET*", Version: ">=1.0.0"}, endpoint: "search", index: 3},
+		{rule: routingRule{Domain: "ONDC:RET*", Version: ">=1.0.0"}, endpoint: "search", index: 4},
+		{rule: routingRule{Domain: "ONDC:RET*", Version: ">=1.0.0"}, endpoint: "search", predicates: cond, index: 5},
+		{rule: routingRule{Domain: "ONDC:RET*", Version: ">=1.0.0"}, endpoint: "search", predicates: cond, index: 6},
+	}
+	sortPatternRules(rules)
+	var got []int
+	for _, pr := range rules {
+		got = append(got, pr.index)
+	}
+	if want := []int{5, 6, 4, 3, 2, 1, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortPatternRules() order = %v, want %v", got, want)
+	}
+}
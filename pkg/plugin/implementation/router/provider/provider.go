@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/router"
+)
+
+// RouterProvider provides instances of Router.
+type RouterProvider struct{}
+
+// New initializes a new Router instance.
+func (rp RouterProvider) New(ctx context.Context, config map[string]string) (definition.Router, func() error, error) {
+	if ctx == nil {
+		return nil, nil, errors.New("context cannot be nil")
+	}
+
+	// Parse the routingConfig key from the config map
+	routingConfig, ok := config["routingConfig"]
+	if !ok {
+		return nil, nil, errors.New("routingConfig is required in the configuration")
+	}
+	cfg := &router.Config{
+		RoutingConfig: routingConfig,
+	}
+	if v, ok := config["watchInterval"]; ok {
+		interval, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid watchInterval %q: %w", v, err)
+		}
+		cfg.WatchInterval = interval
+	}
+	return router.New(ctx, cfg)
+}
+
+// Provider is the exported symbol that the plugin manager will look for.
+var Provider = RouterProvider{}
+
+func init() {
+	if err := plugin.Register("router", &Provider); err != nil {
+		log.Errorf(context.Background(), err, "Failed to register router plugin")
+	}
+}
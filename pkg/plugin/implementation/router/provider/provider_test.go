@@ -1,4 +1,4 @@
-package main
+package provider
 
 import (
 	"context"
@@ -76,6 +76,15 @@ func TestRouterProviderFailure(t *testing.T) {
 			config:  map[string]string{},
 			wantErr: "routingConfig is required in the configuration",
 		},
+		{
+			name: "Invalid watch interval",
+			ctx:  context.Background(),
+			config: map[string]string{
+				"routingConfig": rulesFilePath,
+				"watchInterval": "often",
+			},
+			wantErr: "invalid watchInterval",
+		},
 		{
 			name:    "Nil context",
 			ctx:     nil,
@@ -2,13 +2,15 @@ package router
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
 	"path"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/beckn-one/beckn-onix/pkg/log"
 	"github.com/beckn-one/beckn-onix/pkg/model"
 
 	"gopkg.in/yaml.v3"
@@ -17,6 +19,9 @@ import (
 // Config holds the configuration for the Router plugin.
 type Config struct {
 	RoutingConfig string `json:"routingConfig"`
+	// WatchInterval is how often the routing config file is checked for changes.
+	// A zero value disables reloading.
+	WatchInterval time.Duration `json:"watchInterval"`
 }
 
 // RoutingConfig represents the structure of the routing configuration file.
@@ -26,31 +31,48 @@ type routingConfig struct {
 
 // Router implements Router interface.
 type Router struct {
-	rules map[string]map[string]map[string]*model.Route // domain -> version -> endpoint -> route
+	mu         sync.RWMutex                                  // mu guards swapping of rules on reload.
+	rules      map[string]map[string]map[string]*model.Route // domain -> version -> endpoint -> default route
+	matchRules map[string]map[string]map[string][]matchRoute // domain -> version -> endpoint -> routes with conditions, in file order
+	patterns   []patternRule                                 // Rules with a glob domain, version constraint or * endpoint, most specific first
+	splits     map[*model.Route]*trafficSplit                // Traffic splits of the routes of rules with weighted targets
 }
 
 // RoutingRule represents a single routing rule.
 type routingRule struct {
-	Domain     string   `yaml:"domain"`
-	Version    string   `yaml:"version"`
-	TargetType string   `yaml:"targetType"` // "url", "publisher", "bpp", or "bap"
+	Domain     string   `yaml:"domain"`     // Exact domain or a glob such as ONDC:RET*
+	Version    string   `yaml:"version"`    // Exact version or a semver constraint such as >=1.1.0 <2.0.0
+	TargetType string   `yaml:"targetType"` // "url", "publisher", "bpp", "bap" or "broadcast"
 	Target     target   `yaml:"target,omitempty"`
-	Endpoints  []string `yaml:"endpoints"`
+	Endpoints  []string `yaml:"endpoints"` // Endpoints of the rule, or * for every endpoint
+	// Match restricts the rule to requests whose body matches all of the conditions.
+	// Rules with conditions are tried in file order and the first match wins; a rule
+	// without conditions is the default when none of them match.
+	Match []condition `yaml:"match,omitempty"`
 }
 
 // Target contains destination-specific details.
 type target struct {
-	URL         string `yaml:"url,omitempty"`         // URL for "url" or gateway endpoint for "bpp"/"bap"
-	PublisherID string `yaml:"publisherId,omitempty"` // For "msgq" type
-	ExcludeAction bool `yaml:"excludeAction,omitempty"` // For "url" type to exclude appending action to URL path
+	URL            string           `yaml:"url,omitempty"`            // URL for "url" or gateway endpoint for "bpp"/"bap"
+	Targets        []weightedTarget `yaml:"targets,omitempty"`        // Weighted URLs for "url", instead of url
+	Sticky         bool             `yaml:"sticky,omitempty"`         // For "url" with targets to send all messages of a transaction to the same URL
+	PublisherID    string           `yaml:"publisherId,omitempty"`    // For "msgq" type
+	ExcludeAction  bool             `yaml:"excludeAction,omitempty"`  // For "url" and "broadcast" types to exclude appending action to URL path
+	URLs           []string         `yaml:"urls,omitempty"`           // Static destinations for "broadcast"
+	RegistryLookup bool             `yaml:"registryLookup,omitempty"` // For "broadcast" to look up destinations in the registry by domain
+	SubscriberType string           `yaml:"subscriberType,omitempty"` // Registry subscriber type for "broadcast" lookups, defaults to BPP
 }
 
+// defaultSubscriberType is the registry subscriber type broadcast to when a rule does not set one.
+const defaultSubscriberType = "BPP"
+
 // TargetType defines possible target destinations.
 const (
 	targetTypeURL       = "url"       // Route to a specific URL
 	targetTypePublisher = "publisher" // Route to a publisher
 	targetTypeBPP       = "bpp"       // Route to a BPP endpoint
 	targetTypeBAP       = "bap"       // Route to a BAP endpoint
+	targetTypeBroadcast = "broadcast" // Route to several endpoints
 )
 
 // New initializes a new Router instance with the provided configuration.
@@ -65,15 +87,63 @@ func New(ctx context.Context, config *Config) (*Router, func() error, error) {
 		rules: make(map[string]map[string]map[string]*model.Route),
 	}
 
+	// Record the file state before loading so that a change made right after is not missed.
+	last, _ := os.Stat(config.RoutingConfig)
+
 	// Load rules at bootup
-	if err := router.loadRules(config.RoutingConfig); err != nil {
+	if err := router.loadRules(ctx, config.RoutingConfig); err != nil {
 		return nil, nil, fmt.Errorf("failed to load routing rules: %w", err)
 	}
-	return router, nil, nil
+	if config.WatchInterval <= 0 {
+		return router, nil, nil
+	}
+	done := make(chan struct{})
+	go router.watchRules(ctx, config.RoutingConfig, config.WatchInterval, last, done)
+	return router, func() error {
+		close(done)
+		return nil
+	}, nil
+}
+
+// watchRules reloads the routing rules once the modification time or size of the
+// config file has changed and then stayed the same for one interval, so that a file
+// being written is not read half-way. An invalid or empty file is rejected and the
+// last good rules are kept.
+func (r *Router) watchRules(ctx context.Context, configPath string, interval time.Duration, last os.FileInfo, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	changed := false
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			fi, err := os.Stat(configPath)
+			if err != nil {
+				log.Errorf(ctx, err, "Failed to stat routing config %s", configPath)
+				continue
+			}
+			if last == nil || !fi.ModTime().Equal(last.ModTime()) || fi.Size() != last.Size() {
+				last = fi
+				changed = true
+				continue
+			}
+			if !changed {
+				continue
+			}
+			changed = false
+			if err := r.loadRules(ctx, configPath); err != nil {
+				log.Errorf(ctx, err, "Routing rules reload rejected, keeping last good rules")
+				continue
+			}
+			log.Infof(ctx, "Routing rules reloaded from %s", configPath)
+		}
+	}
 }
 
-// LoadRules reads and parses routing rules from the YAML configuration file.
-func (r *Router) loadRules(configPath string) error {
+// LoadRules reads and parses routing rules from the YAML configuration file
+// and swaps them in once they have been validated and built. Overlapping rules are logged.
+func (r *Router) loadRules(ctx context.Context, configPath string) error {
 	if configPath == "" {
 		return fmt.Errorf("routingConfig path is empty")
 	}
@@ -85,63 +155,140 @@ func (r *Router) loadRules(configPath string) error {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return fmt.Errorf("error parsing YAML: %w", err)
 	}
+	if len(config.RoutingRules) == 0 {
+		return fmt.Errorf("no routing rules in %s", configPath)
+	}
 
 	// Validate rules
 	if err := validateRules(config.RoutingRules); err != nil {
 		return fmt.Errorf("invalid routing rules: %w", err)
 	}
-	// Build the optimized rule map
-	for _, rule := range config.RoutingRules {
-		// Initialize domain map if not exists
-		if _, ok := r.rules[rule.Domain]; !ok {
-			r.rules[rule.Domain] = make(map[string]map[string]*model.Route)
+	built, err := buildRules(config.RoutingRules)
+	if err != nil {
+		return err
+	}
+	for _, overlap := range overlaps(config.RoutingRules) {
+		log.Warnf(ctx, "Routing config %s: %s", configPath, overlap)
+	}
+	r.mu.Lock()
+	r.rules = built.rules
+	r.matchRules = built.matchRules
+	r.patterns = built.patterns
+	r.splits = built.splits
+	r.mu.Unlock()
+	return nil
+}
+
+// buildRules builds a router with the optimized rule maps of the validated routing rules:
+// the default route of each endpoint, the routes of rules with match conditions and
+// the rules with patterns, ordered by precedence.
+func buildRules(routingRules []routingRule) (*Router, error) {
+	rules := make(map[string]map[string]map[string]*model.Route)
+	matchRules := make(map[string]map[string]map[string][]matchRoute)
+	splits := make(map[*model.Route]*trafficSplit)
+	var patterns []patternRule
+	for i, rule := range routingRules {
+		predicates, err := compileConditions(rule.Match)
+		if err != nil {
+			return nil, err
 		}
+		split := newTrafficSplit(rule.Target)
+		if isDomainPattern(rule.Domain) || isVersionConstraint(rule.Version) {
+			for _, endpoint := range rule.Endpoints {
+				pr, err := newPatternRule(rule, endpoint, predicates, i)
+				if err != nil {
+					return nil, err
+				}
+				pr.split = split
+				patterns = append(patterns, pr)
+			}
+			continue
+		}
+		if len(predicates) > 0 {
+			if _, ok := matchRules[rule.Domain]; !ok {
+				matchRules[rule.Domain] = make(map[string]map[string][]matchRoute)
+			}
+			if _, ok := matchRules[rule.Domain][rule.Version]; !ok {
+				matchRules[rule.Domain][rule.Version] = make(map[string][]matchRoute)
+			}
+		} else {
+			// Initialize domain map if not exists
+			if _, ok := rules[rule.Domain]; !ok {
+				rules[rule.Domain] = make(map[string]map[string]*model.Route)
+			}
 
-		// Initialize version map if not exists
-		if _, ok := r.rules[rule.Domain][rule.Version]; !ok {
-			r.rules[rule.Domain][rule.Version] = make(map[string]*model.Route)
+			// Initialize version map if not exists
+			if _, ok := rules[rule.Domain][rule.Version]; !ok {
+				rules[rule.Domain][rule.Version] = make(map[string]*model.Route)
+			}
 		}
 
 		// Add all endpoints for this rule
 		for _, endpoint := range rule.Endpoints {
-			var route *model.Route
-			switch rule.TargetType {
-			case targetTypePublisher:
-				route = &model.Route{
-					TargetType:  rule.TargetType,
-					PublisherID: rule.Target.PublisherID,
-				}
-			case targetTypeURL:
-				parsedURL, err := url.Parse(rule.Target.URL)
-				if err != nil {
-					return fmt.Errorf("invalid URL in rule: %w", err)
-				}
-				if !rule.Target.ExcludeAction {
-					parsedURL.Path = joinPath(parsedURL, endpoint)
-				}
-				route = &model.Route{
-					TargetType: rule.TargetType,
-					URL:        parsedURL,
-				}
-			case targetTypeBPP, targetTypeBAP:
-				var parsedURL *url.URL
-				if rule.Target.URL != "" {
-					parsedURL, err = url.Parse(rule.Target.URL)
-					if err != nil {
-						return fmt.Errorf("invalid URL in rule: %w", err)
-					}
-					parsedURL.Path = joinPath(parsedURL, endpoint)
-				}
-				route = &model.Route{
-					TargetType: rule.TargetType,
-					URL:        parsedURL,
-				}
+			if endpoint == anyEndpoint {
+				patterns = append(patterns, patternRule{rule: rule, endpoint: endpoint, predicates: predicates, split: split, index: i})
+				continue
+			}
+			route, err := buildRoute(rule, endpoint)
+			if err != nil {
+				return nil, err
 			}
-			r.rules[rule.Domain][rule.Version][endpoint] = route
+			if split != nil {
+				splits[route] = split
+			}
+			if len(predicates) > 0 {
+				versionRules := matchRules[rule.Domain][rule.Version]
+				versionRules[endpoint] = append(versionRules[endpoint], matchRoute{predicates: predicates, route: route})
+				continue
+			}
+			rules[rule.Domain][rule.Version][endpoint] = route
 		}
 	}
+	sortPatternRules(patterns)
+	return &Router{rules: rules, matchRules: matchRules, patterns: patterns, splits: splits}, nil
+}
 
-	return nil
+// buildRoute builds the route of a rule for an endpoint.
+func buildRoute(rule routingRule, endpoint string) (*model.Route, error) {
+	switch rule.TargetType {
+	case targetTypePublisher:
+		return &model.Route{
+			TargetType:  rule.TargetType,
+			PublisherID: rule.Target.PublisherID,
+		}, nil
+	case targetTypeURL:
+		if len(rule.Target.Targets) > 0 {
+			return weightedRoute(rule, endpoint)
+		}
+		parsedURL, err := url.Parse(rule.Target.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in rule: %w", err)
+		}
+		if !rule.Target.ExcludeAction {
+			parsedURL.Path = joinPath(parsedURL, endpoint)
+		}
+		return &model.Route{
+			TargetType: rule.TargetType,
+			URL:        parsedURL,
+		}, nil
+	case targetTypeBroadcast:
+		return broadcastRoute(rule, endpoint)
+	case targetTypeBPP, targetTypeBAP:
+		var parsedURL *url.URL
+		if rule.Target.URL != "" {
+			var err error
+			parsedURL, err = url.Parse(rule.Target.URL)
+			if err != nil {
+				return nil, fmt.Errorf("invalid URL in rule: %w", err)
+			}
+			parsedURL.Path = joinPath(parsedURL, endpoint)
+		}
+		return &model.Route{
+			TargetType: rule.TargetType,
+			URL:        parsedURL,
+		}, nil
+	}
+	return nil, nil
 }
 
 // validateRules performs basic validation on the loaded routing rules.
@@ -151,10 +298,30 @@ func validateRules(rules []routingRule) error {
 		if rule.Domain == "" || rule.Version == "" || rule.TargetType == "" {
 			return fmt.Errorf("invalid rule: domain, version, and targetType are required")
 		}
+		if _, err := compileConditions(rule.Match); err != nil {
+			return err
+		}
+		if _, err := path.Match(rule.Domain, ""); err != nil {
+			return fmt.Errorf("invalid rule: domain pattern %q: %w", rule.Domain, err)
+		}
+		if isVersionConstraint(rule.Version) {
+			if _, err := parseVersionConstraint(rule.Version); err != nil {
+				return fmt.Errorf("invalid rule: version %q: %w", rule.Version, err)
+			}
+		}
 
 		// Validate based on TargetType
 		switch rule.TargetType {
 		case targetTypeURL:
+			if len(rule.Target.Targets) > 0 {
+				if rule.Target.URL != "" {
+					return fmt.Errorf("invalid rule: url and targets cannot both be set for targetType 'url'")
+				}
+				if err := validateTargets(rule.Target.Targets); err != nil {
+					return err
+				}
+				continue
+			}
 			if rule.Target.URL == "" {
 				return fmt.Errorf("invalid rule: url is required for targetType 'url'")
 			}
@@ -165,6 +332,18 @@ func validateRules(rules []routingRule) error {
 			if rule.Target.PublisherID == "" {
 				return fmt.Errorf("invalid rule: publisherID is required for targetType 'publisher'")
 			}
+		case targetTypeBroadcast:
+			if len(rule.Target.URLs) == 0 && !rule.Target.RegistryLookup {
+				return fmt.Errorf("invalid rule: urls or registryLookup is required for targetType 'broadcast'")
+			}
+			if len(rule.Target.URLs) > 0 && rule.Target.RegistryLookup {
+				return fmt.Errorf("invalid rule: urls and registryLookup cannot both be set for targetType 'broadcast'")
+			}
+			for _, u := range rule.Target.URLs {
+				if _, err := url.Parse(u); err != nil {
+					return fmt.Errorf("invalid URL - %s: %w", u, err)
+				}
+			}
 		case targetTypeBPP, targetTypeBAP:
 			if rule.Target.URL != "" {
 				if _, err := url.Parse(rule.Target.URL); err != nil {
@@ -181,16 +360,9 @@ func validateRules(rules []routingRule) error {
 
 // Route determines the routing destination based on the request context.
 func (r *Router) Route(ctx context.Context, url *url.URL, body []byte) (*model.Route, error) {
-	// Parse the body to extract domain and version
-	var requestBody struct {
-		Context struct {
-			Domain  string `json:"domain"`
-			Version string `json:"version"`
-			BPPURI  string `json:"bpp_uri,omitempty"`
-			BAPURI  string `json:"bap_uri,omitempty"`
-		} `json:"context"`
-	}
-	if err := json.Unmarshal(body, &requestBody); err != nil {
+	// Extract domain and version from the context of the request
+	bc, err := model.BecknContextFor(ctx, body)
+	if err != nil {
 		return nil, fmt.Errorf("error parsing request body: %w", err)
 	}
 
@@ -198,31 +370,130 @@ func (r *Router) Route(ctx context.Context, url *url.URL, body []byte) (*model.R
 	endpoint := path.Base(url.Path)
 
 	// Lookup route in the optimized map
-	domainRules, ok := r.rules[requestBody.Context.Domain]
+	r.mu.RLock()
+	rules, matchRules, patterns, splits := r.rules, r.matchRules, r.patterns, r.splits
+	r.mu.RUnlock()
+	domain, version := bc.Domain, bc.Version
+	route, err := defaultRoute(rules, domain, version, endpoint)
+	split := splits[route]
+
+	// Rules with conditions take precedence over the default route of the endpoint.
+	candidates := matchRules[domain][version][endpoint]
+	if len(candidates) > 0 {
+		matched, matchErr := firstMatch(candidates, body)
+		if matchErr != nil {
+			return nil, matchErr
+		}
+		if matched != nil {
+			route, split, err = matched, splits[matched], nil
+		}
+	}
+
+	// Rules with patterns apply when no exact rule matches.
+	if route == nil {
+		matched, selected, matchErr := matchPatterns(patterns, domain, version, endpoint, body)
+		switch {
+		case matchErr != nil:
+			return nil, matchErr
+		case matched != nil:
+			if route, err = buildRoute(matched.rule, endpoint); err != nil {
+				return nil, err
+			}
+			split = matched.split
+		case selected || len(candidates) > 0:
+			err = fmt.Errorf("no routing rule matched the request for endpoint '%s', domain %s and version %s",
+				endpoint, domain, version)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if split != nil {
+		i := split.pick(bc.TransactionID)
+		log.Infof(ctx, "Routing %s of transaction %s to %s (weight %d of %d)",
+			endpoint, bc.TransactionID, route.URLs[i], split.weights[i], split.total)
+		return &model.Route{TargetType: targetTypeURL, URL: route.URLs[i]}, nil
+	}
+	// Handle BPP/BAP routing with request URIs
+	switch route.TargetType {
+	case targetTypeBPP:
+		return handleProtocolMapping(route, bc.BPPURI, endpoint)
+	case targetTypeBAP:
+		return handleProtocolMapping(route, bc.BAPURI, endpoint)
+	case targetTypeBroadcast:
+		if route.Lookup != nil {
+			// Look up the subscribers of the request's domain.
+			lookup := *route.Lookup
+			lookup.Domain = bc.Domain
+			return &model.Route{TargetType: targetTypeBroadcast, Lookup: &lookup}, nil
+		}
+	}
+	return route, nil
+}
+
+// defaultRoute returns the route of the rule without conditions for an endpoint.
+func defaultRoute(rules map[string]map[string]map[string]*model.Route, domain, version, endpoint string) (*model.Route, error) {
+	domainRules, ok := rules[domain]
 	if !ok {
-		return nil, fmt.Errorf("no routing rules found for domain %s", requestBody.Context.Domain)
+		return nil, fmt.Errorf("no routing rules found for domain %s", domain)
 	}
 
-	versionRules, ok := domainRules[requestBody.Context.Version]
+	versionRules, ok := domainRules[version]
 	if !ok {
-		return nil, fmt.Errorf("no routing rules found for domain %s version %s", requestBody.Context.Domain, requestBody.Context.Version)
+		return nil, fmt.Errorf("no routing rules found for domain %s version %s", domain, version)
 	}
 
 	route, ok := versionRules[endpoint]
 	if !ok {
 		return nil, fmt.Errorf("endpoint '%s' is not supported for domain %s and version %s in routing config",
-			endpoint, requestBody.Context.Domain, requestBody.Context.Version)
-	}
-	// Handle BPP/BAP routing with request URIs
-	switch route.TargetType {
-	case targetTypeBPP:
-		return handleProtocolMapping(route, requestBody.Context.BPPURI, endpoint)
-	case targetTypeBAP:
-		return handleProtocolMapping(route, requestBody.Context.BAPURI, endpoint)
+			endpoint, domain, version)
 	}
 	return route, nil
 }
 
+// weightedRoute builds the route of a "url" rule with weighted targets for an endpoint.
+// It holds the URLs of all targets, one of which is chosen for each request.
+func weightedRoute(rule routingRule, endpoint string) (*model.Route, error) {
+	urls := make([]*url.URL, 0, len(rule.Target.Targets))
+	for _, wt := range rule.Target.Targets {
+		parsedURL, err := url.Parse(wt.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in rule: %w", err)
+		}
+		if !rule.Target.ExcludeAction {
+			parsedURL.Path = joinPath(parsedURL, endpoint)
+		}
+		urls = append(urls, parsedURL)
+	}
+	return &model.Route{TargetType: targetTypeURL, URLs: urls}, nil
+}
+
+// broadcastRoute builds the route of a broadcast rule for an endpoint.
+func broadcastRoute(rule routingRule, endpoint string) (*model.Route, error) {
+	if rule.Target.RegistryLookup {
+		subscriberType := rule.Target.SubscriberType
+		if subscriberType == "" {
+			subscriberType = defaultSubscriberType
+		}
+		return &model.Route{
+			TargetType: targetTypeBroadcast,
+			Lookup:     &model.Subscriber{Type: subscriberType, Domain: rule.Domain},
+		}, nil
+	}
+	urls := make([]*url.URL, 0, len(rule.Target.URLs))
+	for _, u := range rule.Target.URLs {
+		parsedURL, err := url.Parse(u)
+		if err != nil {
+			return nil, fmt.Errorf("invalid URL in rule: %w", err)
+		}
+		if !rule.Target.ExcludeAction {
+			parsedURL.Path = joinPath(parsedURL, endpoint)
+		}
+		urls = append(urls, parsedURL)
+	}
+	return &model.Route{TargetType: targetTypeBroadcast, URLs: urls}, nil
+}
+
 // handleProtocolMapping handles both BPP and BAP routing with proper URL construction
 func handleProtocolMapping(route *model.Route, npURI, endpoint string) (*model.Route, error) {
 	target := strings.TrimSpace(npURI)
@@ -251,4 +522,4 @@ func joinPath(u *url.URL, endpoint string) string {
 		u.Path = "/"
 	}
 	return path.Join(u.Path, endpoint)
-}
\ No newline at end of file
+}
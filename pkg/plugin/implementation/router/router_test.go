@@ -3,12 +3,15 @@ package router
 import (
 	"context"
 	"embed"
+	"fmt"
 	"net/url"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/beckn-one/beckn-onix/pkg/model"
 )
@@ -116,7 +119,7 @@ func TestLoadRules(t *testing.T) {
 	rulesFilePath := setupTestConfig(t, "valid_all_routes.yaml")
 	defer os.RemoveAll(filepath.Dir(rulesFilePath))
 
-	err := router.loadRules(rulesFilePath)
+	err := router.loadRules(context.Background(), rulesFilePath)
 	if err != nil {
 		t.Fatalf("loadRules() err = %v, want nil", err)
 	}
@@ -182,7 +185,7 @@ func TestLoadRulesErrors(t *testing.T) {
 				defer os.RemoveAll(filepath.Dir(tt.configPath))
 			}
 
-			err := router.loadRules(tt.configPath)
+			err := router.loadRules(context.Background(), tt.configPath)
 			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
 				t.Errorf("loadRules(%q) = %v, want error containing %q", tt.configPath, err, tt.wantErr)
 			}
@@ -260,6 +263,34 @@ func TestValidateRulesSuccess(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Valid rules with broadcast to static urls",
+			rules: []routingRule{
+				{
+					Domain:     "retail",
+					Version:    "1.0.0",
+					TargetType: "broadcast",
+					Target: target{
+						URLs: []string{"https://bpp1.example.com", "https://bpp2.example.com"},
+					},
+					Endpoints: []string{"search"},
+				},
+			},
+		},
+		{
+			name: "Valid rules with broadcast to registry subscribers",
+			rules: []routingRule{
+				{
+					Domain:     "retail",
+					Version:    "1.0.0",
+					TargetType: "broadcast",
+					Target: target{
+						RegistryLookup: true,
+					},
+					Endpoints: []string{"search"},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -606,7 +637,7 @@ func TestExcludeAction(t *testing.T) {
 			rulesFilePath := setupTestConfig(t, tt.configFile)
 			defer os.RemoveAll(filepath.Dir(rulesFilePath))
 
-			err := router.loadRules(rulesFilePath)
+			err := router.loadRules(context.Background(), rulesFilePath)
 			if err != nil {
 				t.Fatalf("loadRules() err = %v, want nil", err)
 			}
@@ -671,7 +702,7 @@ func TestExcludeActionWithNonURLTargetTypes(t *testing.T) {
 			rulesFilePath := setupTestConfig(t, tt.configFile)
 			defer os.RemoveAll(filepath.Dir(rulesFilePath))
 
-			err := router.loadRules(rulesFilePath)
+			err := router.loadRules(context.Background(), rulesFilePath)
 			if err != nil {
 				t.Fatalf("loadRules() err = %v, want nil", err)
 			}
@@ -681,4 +712,420 @@ func TestExcludeActionWithNonURLTargetTypes(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// TestRulesReload tests that changes to the routing config are picked up and invalid changes are rejected.
+func TestRulesReload(t *testing.T) {
+	rulesFilePath := setupTestConfig(t, "bap_receiver.yaml")
+	router, closer, err := New(context.Background(), &Config{
+		RoutingConfig: rulesFilePath,
+		WatchInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() err = %v, want nil", err)
+	}
+	defer closer()
+
+	body := []byte(`{"context": {"domain": "ONDC:TRV11", "version": "2.0.0"}}`)
+	u, _ := url.Parse("https://example.com/on_select")
+	if _, err := router.Route(context.Background(), u, body); err == nil {
+		t.Fatal("Route() err = nil before reload, want error for unknown domain")
+	}
+
+	// waitForRoute polls until the route resolves to want or the deadline passes.
+	waitForRoute := func(want string) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if route, err := router.Route(context.Background(), u, body); err == nil && route.URL.String() == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("Route() did not resolve to %s after reload", want)
+	}
+
+	valid := `routingRules:
+  - domain: ONDC:TRV11
+    version: 2.0.0
+    targetType: url
+    target:
+      url: https://new-backend/trv
+    endpoints:
+      - on_select
+`
+	if err := os.WriteFile(rulesFilePath, []byte(valid), 0644); err != nil {
+		t.Fatalf("WriteFile() err = %v, want nil", err)
+	}
+	waitForRoute("https://new-backend/trv/on_select")
+
+	// An invalid, blank or half-written file must leave the last good rules in place.
+	for name, content := range map[string]string{
+		"invalid": `routingRules:
+  - domain: ONDC:TRV11
+    version: 2.0.0
+    targetType: unknown
+    endpoints:
+      - on_select
+`,
+		"blank":        "  \n\n",
+		"half-written": valid[:strings.Index(valid, "target:")],
+	} {
+		if err := os.WriteFile(rulesFilePath, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile() err = %v, want nil", err)
+		}
+		time.Sleep(100 * time.Millisecond)
+		route, err := router.Route(context.Background(), u, body)
+		if err != nil {
+			t.Fatalf("Route() err = %v after rejected %s reload, want nil", err, name)
+		}
+		if got, want := route.URL.String(), "https://new-backend/trv/on_select"; got != want {
+			t.Errorf("Route() URL = %s after rejected %s reload, want %s", got, name, want)
+		}
+	}
+}
+
+// TestRouteBroadcast tests the routes of broadcast rules.
+func TestRouteBroadcast(t *testing.T) {
+	router, _, _ := setupRouter(t, "broadcast.yaml")
+	searchURL, _ := url.Parse("https://example.com/v1/ondc/search")
+
+	route, err := router.Route(context.Background(), searchURL, []byte(`{"context": {"domain": "ONDC:RET10", "version": "1.2.0"}}`))
+	if err != nil {
+		t.Fatalf("Route() err = %v, want nil", err)
+	}
+	want := &model.Route{TargetType: targetTypeBroadcast, URLs: []*url.URL{
+		parseURL(t, "https://bpp1.example.com/search"),
+		parseURL(t, "https://bpp2.example.com/beckn/search"),
+	}}
+	if !reflect.DeepEqual(route, want) {
+		t.Errorf("Route() = %#v, want %#v", route, want)
+	}
+
+	route, err = router.Route(context.Background(), searchURL, []byte(`{"context": {"domain": "ONDC:RET11", "version": "1.2.0"}}`))
+	if err != nil {
+		t.Fatalf("Route() err = %v, want nil", err)
+	}
+	want = &model.Route{TargetType: targetTypeBroadcast, Lookup: &model.Subscriber{Type: "BPP", Domain: "ONDC:RET11"}}
+	if !reflect.DeepEqual(route, want) {
+		t.Errorf("Route() = %#v, want %#v", route, want)
+	}
+}
+
+// TestValidateBroadcastRulesFailure tests that broadcast rules need exactly one source of destinations.
+func TestValidateBroadcastRulesFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  target
+		wantErr string
+	}{
+		{
+			name:    "no destinations",
+			target:  target{},
+			wantErr: "urls or registryLookup is required",
+		},
+		{
+			name:    "both urls and registryLookup",
+			target:  target{URLs: []string{"https://bpp1.example.com"}, RegistryLookup: true},
+			wantErr: "cannot both be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRules([]routingRule{{Domain: "retail", Version: "1.0.0", TargetType: "broadcast", Target: tt.target, Endpoints: []string{"search"}}})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateRules() err = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRouteMatch tests that the first rule whose conditions match the request wins,
+// and that the rule without conditions is the default.
+func TestRouteMatch(t *testing.T) {
+	router, _, _ := setupRouter(t, "match.yaml")
+	tests := []struct {
+		name     string
+		endpoint string
+		body     string
+		wantURL  string
+		wantErr  string
+	}{
+		{
+			name:     "city",
+			endpoint: "search",
+			body:     `{"context": {"domain": "ONDC:RET10", "version": "1.2.0", "location": {"city": {"code": "std:080"}}}}`,
+			wantURL:  "https://blr.bpp-cluster.example.com/search",
+		},
+		{
+			name:     "bpp_id and country",
+			endpoint: "search",
+			body:     `{"context": {"domain": "ONDC:RET10", "version": "1.2.0", "bpp_id": "bpp.sandbox.example.com", "country": "IND"}}`,
+			wantURL:  "https://sandbox.example.com/search",
+		},
+		{
+			name:     "first match wins",
+			endpoint: "search",
+			body:     `{"context": {"domain": "ONDC:RET10", "version": "1.2.0", "bpp_id": "bpp.sandbox.example.com", "country": "IND", "location": {"city": {"code": "std:080"}}}}`,
+			wantURL:  "https://blr.bpp-cluster.example.com/search",
+		},
+		{
+			name:     "message field",
+			endpoint: "search",
+			body:     `{"context": {"domain": "ONDC:RET10", "version": "1.2.0"}, "message": {"intent": {"category": {"descriptor": {"code": "F&B"}}}}}`,
+			wantURL:  "https://grocery.example.com/search",
+		},
+		{
+			name:     "partial match falls back to default",
+			endpoint: "search",
+			body:     `{"context": {"domain": "ONDC:RET10", "version": "1.2.0", "bpp_id": "bpp.sandbox.example.com", "country": "USA"}}`,
+			wantURL:  "https://default.example.com/search",
+		},
+		{
+			name:     "no match falls back to default",
+			endpoint: "search",
+			body:     `{"context": {"domain": "ONDC:RET10", "version": "1.2.0", "location": {"city": {"code": "std:011"}}}}`,
+			wantURL:  "https://default.example.com/search",
+		},
+		{
+			name:     "match without default",
+			endpoint: "select",
+			body:     `{"context": {"domain": "ONDC:RET10", "version": "1.2.0", "bpp_id": "bpp.sandbox.example.com", "country": "IND"}}`,
+			wantURL:  "https://sandbox.example.com/select",
+		},
+		{
+			name:     "no match without default",
+			endpoint: "select",
+			body:     `{"context": {"domain": "ONDC:RET10", "version": "1.2.0", "bpp_id": "bpp.example.com", "country": "IND"}}`,
+			wantErr:  "no routing rule matched the request for endpoint 'select'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, err := router.Route(context.Background(), parseURL(t, "https://example.com/v1/"+tt.endpoint), []byte(tt.body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Route() err = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Route() err = %v, want nil", err)
+			}
+			if got := route.URL.String(); got != tt.wantURL {
+				t.Errorf("Route() URL = %s, want %s", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+// TestValidateMatchRulesFailure tests the validation of rule conditions.
+func TestValidateMatchRulesFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		match   []condition
+		wantErr string
+	}{
+		{
+			name:    "no values",
+			match:   []condition{{Path: "$.context.bpp_id"}},
+			wantErr: `values are required for match path "$.context.bpp_id"`,
+		},
+		{
+			name:    "invalid path",
+			match:   []condition{{Path: "$.message.items[x]", Values: []string{"1"}}},
+			wantErr: `invalid rule: match path "$.message.items[x]": invalid array index "x"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRules([]routingRule{{Domain: "retail", Version: "1.0.0", TargetType: "url", Target: target{URL: "https://example.com"}, Endpoints: []string{"search"}, Match: tt.match}})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateRules() err = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRoutePatterns tests that rules with glob domains, version constraints and
+// * endpoints apply from the most to the least specific.
+func TestRoutePatterns(t *testing.T) {
+	router, _, _ := setupRouter(t, "pattern.yaml")
+	tests := []struct {
+		name     string
+		endpoint string
+		domain   string
+		version  string
+		wantURL  string
+		wantErr  string
+	}{
+		{
+			name:     "exact rule",
+			endpoint: "search", domain: "ONDC:RET10", version: "1.2.0",
+			wantURL: "https://ret10-v120-search.example.com/search",
+		},
+		{
+			name:     "exact domain and version with any endpoint",
+			endpoint: "select", domain: "ONDC:RET10", version: "1.2.0",
+			wantURL: "https://ret10-v120.example.com/select",
+		},
+		{
+			name:     "exact domain with any version",
+			endpoint: "search", domain: "ONDC:RET10", version: "1.1.0",
+			wantURL: "https://ret10.example.com/search",
+		},
+		{
+			name:     "longer glob",
+			endpoint: "search", domain: "ONDC:RET11", version: "1.1.0",
+			wantURL: "https://retail-1x.example.com/search",
+		},
+		{
+			name:     "shorter glob",
+			endpoint: "search", domain: "ONDC:RET2", version: "1.9.9",
+			wantURL: "https://retail.example.com/search",
+		},
+		{
+			name:     "glob with any endpoint",
+			endpoint: "init", domain: "ONDC:RET11", version: "1.1.0",
+			wantURL: "https://retail.example.com/init",
+		},
+		{
+			name:     "version out of range",
+			endpoint: "init", domain: "ONDC:RET11", version: "2.0.0",
+			wantErr: "no routing rules found for domain ONDC:RET11",
+		},
+		{
+			name:     "domain not matched",
+			endpoint: "search", domain: "ONDC:TRV10", version: "1.1.0",
+			wantErr: "no routing rules found for domain ONDC:TRV10",
+		},
+		{
+			name:     "conditions not matched",
+			endpoint: "confirm", domain: "ONDC:RET11", version: "2.0.0",
+			wantErr: "no routing rule matched the request for endpoint 'confirm'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fmt.Sprintf(`{"context": {"domain": %q, "version": %q}}`, tt.domain, tt.version)
+			route, err := router.Route(context.Background(), parseURL(t, "https://example.com/v1/"+tt.endpoint), []byte(body))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Route() err = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Route() err = %v, want nil", err)
+			}
+			if got := route.URL.String(); got != tt.wantURL {
+				t.Errorf("Route() URL = %s, want %s", got, tt.wantURL)
+			}
+		})
+	}
+
+	// A pattern rule with conditions applies when they match.
+	body := `{"context": {"domain": "ONDC:RET11", "version": "2.0.0", "location": {"city": {"code": "std:080"}}}}`
+	route, err := router.Route(context.Background(), parseURL(t, "https://example.com/v1/confirm"), []byte(body))
+	if err != nil {
+		t.Fatalf("Route() err = %v, want nil", err)
+	}
+	if got, want := route.URL.String(), "https://blr.example.com/confirm"; got != want {
+		t.Errorf("Route() URL = %s, want %s", got, want)
+	}
+}
+
+// TestValidatePatternRulesFailure tests the validation of domain globs and version constraints.
+func TestValidatePatternRulesFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		domain  string
+		version string
+		wantErr string
+	}{
+		{name: "invalid glob", domain: "ONDC:RET[", version: "1.0.0", wantErr: `invalid rule: domain pattern "ONDC:RET["`},
+		{name: "unsupported operator", domain: "retail", version: "~1.0.0", wantErr: `invalid rule: version "~1.0.0": unsupported operator "~"`},
+		{name: "invalid version", domain: "retail", version: ">=1.x", wantErr: `invalid rule: version ">=1.x": invalid version "1.x"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRules([]routingRule{{Domain: tt.domain, Version: tt.version, TargetType: "url", Target: target{URL: "https://example.com"}, Endpoints: []string{"search"}}})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateRules() err = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestRouteWeighted tests routing to one of several weighted targets.
+func TestRouteWeighted(t *testing.T) {
+	router, _, _ := setupRouter(t, "weighted.yaml")
+	ctx := context.Background()
+
+	// Every message of a transaction goes to the same target.
+	for i := 0; i < 20; i++ {
+		body := fmt.Sprintf(`{"context": {"domain": "ONDC:RET10", "version": "1.2.0", "transaction_id": "txn-%d"}}`, i)
+		var hosts []string
+		for _, endpoint := range []string{"select", "init", "confirm"} {
+			route, err := router.Route(ctx, parseURL(t, "https://example.com/v1/"+endpoint), []byte(body))
+			if err != nil {
+				t.Fatalf("Route() err = %v, want nil", err)
+			}
+			if route.TargetType != targetTypeURL || path.Base(route.URL.Path) != endpoint {
+				t.Fatalf("Route() = %+v, want a url route to %s", route, endpoint)
+			}
+			hosts = append(hosts, route.URL.Host)
+		}
+		if hosts[0] != hosts[1] || hosts[0] != hosts[2] {
+			t.Errorf("transaction txn-%d routed to %v, want one target", i, hosts)
+		}
+	}
+
+	// A target with no weight receives no traffic.
+	for i := 0; i < 20; i++ {
+		route, err := router.Route(ctx, parseURL(t, "https://example.com/v1/search"), []byte(`{"context": {"domain": "ONDC:RET11", "version": "1.2.0"}}`))
+		if err != nil {
+			t.Fatalf("Route() err = %v, want nil", err)
+		}
+		if got, want := route.URL.String(), "https://bpp-new.example.com/search"; got != want {
+			t.Fatalf("Route() URL = %s, want %s", got, want)
+		}
+	}
+}
+
+// TestValidateWeightedRulesFailure tests the validation of weighted targets.
+func TestValidateWeightedRulesFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  target
+		wantErr string
+	}{
+		{
+			name:    "url and targets",
+			target:  target{URL: "https://example.com", Targets: []weightedTarget{{URL: "https://a.example.com", Weight: 1}}},
+			wantErr: "url and targets cannot both be set",
+		},
+		{
+			name:    "missing url",
+			target:  target{Targets: []weightedTarget{{Weight: 1}}},
+			wantErr: "url is required for each of targets",
+		},
+		{
+			name:    "negative weight",
+			target:  target{Targets: []weightedTarget{{URL: "https://a.example.com", Weight: -1}, {URL: "https://b.example.com", Weight: 2}}},
+			wantErr: "weight of https://a.example.com must not be negative",
+		},
+		{
+			name:    "no weight",
+			target:  target{Targets: []weightedTarget{{URL: "https://a.example.com"}}},
+			wantErr: "targets must have a positive total weight",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRules([]routingRule{{Domain: "retail", Version: "1.0.0", TargetType: "url", Target: tt.target, Endpoints: []string{"search"}}})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateRules() err = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package router
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+)
+
+// weightedTarget is one of several URLs a "url" rule splits traffic across.
+type weightedTarget struct {
+	URL    string `yaml:"url"`
+	Weight int    `yaml:"weight"` // Relative share of traffic; 0 sends no new traffic to the URL
+}
+
+// trafficSplit chooses one of the URLs of a weighted rule for each request.
+type trafficSplit struct {
+	urls    []string
+	weights []int
+	total   int
+	sticky  bool
+}
+
+// newTrafficSplit returns the traffic split of a rule, or nil if it has no weighted targets.
+func newTrafficSplit(t target) *trafficSplit {
+	if len(t.Targets) == 0 {
+		return nil
+	}
+	s := &trafficSplit{sticky: t.Sticky}
+	for _, wt := range t.Targets {
+		s.urls = append(s.urls, wt.URL)
+		s.weights = append(s.weights, wt.Weight)
+		s.total += wt.Weight
+	}
+	return s
+}
+
+// validateTargets checks the weighted targets of a "url" rule.
+func validateTargets(targets []weightedTarget) error {
+	total := 0
+	for _, wt := range targets {
+		if wt.URL == "" {
+			return fmt.Errorf("invalid rule: url is required for each of targets")
+		}
+		if wt.Weight < 0 {
+			return fmt.Errorf("invalid rule: weight of %s must not be negative", wt.URL)
+		}
+		total += wt.Weight
+	}
+	if total == 0 {
+		return fmt.Errorf("invalid rule: targets must have a positive total weight")
+	}
+	return nil
+}
+
+// pick returns the index of the target for a request. Sticky splits choose by
+// weighted rendezvous hashing of the transaction ID and the URL of each target, so
+// that every message of a transaction goes to the same target, and a change of the
+// weights only moves the transactions of the share that was shifted. Other requests
+// are assigned at random.
+func (s *trafficSplit) pick(transactionID string) int {
+	if s.sticky && transactionID != "" {
+		best, bestScore := len(s.weights)-1, 0.0
+		for i, w := range s.weights {
+			if w == 0 {
+				continue
+			}
+			if score := rendezvousScore(s.urls[i]+"|"+transactionID, w); score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		return best
+	}
+	n := rand.IntN(s.total)
+	for i, w := range s.weights {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+	return len(s.weights) - 1
+}
+
+// rendezvousScore returns the score of a target of weight for key, a target and
+// transaction pair. The target with the highest score is chosen with a probability
+// proportional to its weight.
+func rendezvousScore(key string, weight int) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// Mix the bits, as FNV spreads keys that differ only in their last bytes poorly.
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	// A uniform value in (0, 1).
+	u := (float64(x>>11) + 0.5) / (1 << 53)
+	return -float64(weight) / math.Log(u)
+}
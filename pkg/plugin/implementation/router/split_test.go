@@ -0,0 +1,88 @@
+package router
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestTrafficSplitPick tests that targets are chosen in proportion to their weights.
+func TestTrafficSplitPick(t *testing.T) {
+	tests := []struct {
+		name   string
+		sticky bool
+		txnID  func(i int) string
+	}{
+		{name: "random", txnID: func(int) string { return "" }},
+		{name: "sticky", sticky: true, txnID: func(i int) string { return fmt.Sprintf("txn-%d", i) }},
+		{name: "sticky without transaction", sticky: true, txnID: func(int) string { return "" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			split := newTrafficSplit(target{Sticky: tt.sticky, Targets: []weightedTarget{
+				{URL: "https://a.example.com", Weight: 80},
+				{URL: "https://b.example.com", Weight: 0},
+				{URL: "https://c.example.com", Weight: 20},
+			}})
+			const n = 10000
+			counts := make([]int, 3)
+			for i := 0; i < n; i++ {
+				counts[split.pick(tt.txnID(i))]++
+			}
+			if counts[1] != 0 {
+				t.Errorf("target with weight 0 picked %d times, want 0", counts[1])
+			}
+			if share := float64(counts[2]) / n; share < 0.17 || share > 0.23 {
+				t.Errorf("target with weight 20 of 100 picked %.2f of the time, want about 0.20", share)
+			}
+		})
+	}
+}
+
+// TestTrafficSplitSticky tests that a transaction is always sent to the same target.
+func TestTrafficSplitSticky(t *testing.T) {
+	split := newTrafficSplit(target{Sticky: true, Targets: []weightedTarget{
+		{URL: "https://a.example.com", Weight: 50},
+		{URL: "https://b.example.com", Weight: 50},
+	}})
+	for i := 0; i < 100; i++ {
+		txnID := fmt.Sprintf("txn-%d", i)
+		want := split.pick(txnID)
+		for j := 0; j < 5; j++ {
+			if got := split.pick(txnID); got != want {
+				t.Fatalf("pick(%q) = %d, want %d", txnID, got, want)
+			}
+		}
+	}
+}
+
+// TestTrafficSplitStickyReweight tests that a change of the weights of a sticky split
+// moves transactions only from the target whose share shrank or to the one whose
+// share grew, and few of them.
+func TestTrafficSplitStickyReweight(t *testing.T) {
+	before := newTrafficSplit(target{Sticky: true, Targets: []weightedTarget{
+		{URL: "https://a.example.com", Weight: 80},
+		{URL: "https://b.example.com", Weight: 10},
+		{URL: "https://c.example.com", Weight: 10},
+	}})
+	after := newTrafficSplit(target{Sticky: true, Targets: []weightedTarget{
+		{URL: "https://a.example.com", Weight: 70},
+		{URL: "https://b.example.com", Weight: 10},
+		{URL: "https://c.example.com", Weight: 20},
+	}})
+	const n = 10000
+	moved := 0
+	for i := 0; i < n; i++ {
+		txnID := fmt.Sprintf("txn-%d", i)
+		from, to := before.pick(txnID), after.pick(txnID)
+		if from == to {
+			continue
+		}
+		moved++
+		if to == 0 || from == 2 {
+			t.Fatalf("pick(%q) moved from %d to %d", txnID, from, to)
+		}
+	}
+	if share := float64(moved) / n; share > 0.13 {
+		t.Errorf("%.2f of transactions moved, want at most 0.13", share)
+	}
+}
@@ -0,0 +1,250 @@
+package router
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// semver is a parsed semantic version. Pre-release and build metadata are ignored.
+type semver [3]int
+
+// parseSemver parses a version such as 1.2.0, v2 or 1.1. Missing components are zero.
+func parseSemver(s string) (semver, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	var v semver
+	parts := strings.Split(s, ".")
+	if len(parts) > len(v) {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v[i] = n
+	}
+	return v, nil
+}
+
+// compare returns -1, 0 or +1 depending on whether v is lower than, equal to or higher than o.
+func (v semver) compare(o semver) int {
+	for i := range v {
+		if c := cmp.Compare(v[i], o[i]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// comparator is a single term of a version constraint, such as >=1.1.0.
+type comparator struct {
+	op      string
+	version semver
+}
+
+// satisfiedBy reports whether v satisfies the comparator.
+func (c comparator) satisfiedBy(v semver) bool {
+	n := v.compare(c.version)
+	switch c.op {
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	case "<":
+		return n < 0
+	case "<=":
+		return n <= 0
+	case "!=":
+		return n != 0
+	default:
+		return n == 0
+	}
+}
+
+// versionConstraint is a semver range such as ">=1.1.0 <2.0.0". Space separated
+// comparators must all hold, and alternatives are separated by ||. A * matches any version.
+type versionConstraint struct {
+	sets [][]comparator
+}
+
+// isVersionConstraint reports whether a rule version is a constraint rather than an exact version.
+func isVersionConstraint(s string) bool {
+	return strings.ContainsAny(s, "<>=!*|~^ ")
+}
+
+// parseVersionConstraint parses a version constraint.
+func parseVersionConstraint(s string) (*versionConstraint, error) {
+	c := &versionConstraint{}
+	for _, alt := range strings.Split(s, "||") {
+		fields := strings.Fields(alt)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("empty version constraint")
+		}
+		set := []comparator{}
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			if f == "*" {
+				continue
+			}
+			op := f[:len(f)-len(strings.TrimLeft(f, "<>=!~^"))]
+			rest := f[len(op):]
+			// Allow a space between the operator and the version, as in ">= 1.1.0".
+			if rest == "" && i+1 < len(fields) {
+				i++
+				rest = fields[i]
+			}
+			switch op {
+			case "", "=", "==":
+				op = "="
+			case ">", ">=", "<", "<=", "!=":
+			default:
+				return nil, fmt.Errorf("unsupported operator %q in version constraint", op)
+			}
+			v, err := parseSemver(rest)
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, comparator{op: op, version: v})
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+// matches reports whether the version satisfies the constraint. Versions that
+// are not valid semver never match.
+func (c *versionConstraint) matches(version string) bool {
+	v, err := parseSemver(version)
+	if err != nil {
+		return false
+	}
+	for _, set := range c.sets {
+		if satisfiesAll(set, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// satisfiesAll reports whether v satisfies every comparator of the set.
+func satisfiesAll(set []comparator, v semver) bool {
+	for _, c := range set {
+		if !c.satisfiedBy(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// bound is one end of a version interval. A nil bound is unbounded.
+type bound struct {
+	version   semver
+	inclusive bool
+}
+
+// interval is the range of versions allowed by a set of comparators.
+// != terms are ignored, so the interval may be wider than the set.
+type interval struct {
+	lo, hi *bound
+}
+
+// intervals returns the version intervals of the constraint, one per alternative.
+func (c *versionConstraint) intervals() []interval {
+	var ivs []interval
+	for _, set := range c.sets {
+		var iv interval
+		for _, term := range set {
+			b := &bound{version: term.version, inclusive: term.op != ">" && term.op != "<"}
+			switch term.op {
+			case ">", ">=":
+				iv.lo = maxLower(iv.lo, b)
+			case "<", "<=":
+				iv.hi = minUpper(iv.hi, b)
+			case "=":
+				iv.lo, iv.hi = maxLower(iv.lo, b), minUpper(iv.hi, b)
+			}
+		}
+		ivs = append(ivs, iv)
+	}
+	return ivs
+}
+
+// maxLower returns the tighter of two lower bounds.
+func maxLower(a, b *bound) *bound {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if n := a.version.compare(b.version); n > 0 || (n == 0 && !a.inclusive) {
+		return a
+	}
+	return b
+}
+
+// minUpper returns the tighter of two upper bounds.
+func minUpper(a, b *bound) *bound {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if n := a.version.compare(b.version); n < 0 || (n == 0 && !a.inclusive) {
+		return a
+	}
+	return b
+}
+
+// overlaps reports whether two intervals share at least one version.
+func (iv interval) overlaps(o interval) bool {
+	return below(maxLower(iv.lo, o.lo), minUpper(iv.hi, o.hi))
+}
+
+// below reports whether a lower bound does not exceed an upper bound.
+func below(lo, hi *bound) bool {
+	if lo == nil || hi == nil {
+		return true
+	}
+	n := lo.version.compare(hi.version)
+	return n < 0 || (n == 0 && lo.inclusive && hi.inclusive)
+}
+
+// versionsOverlap reports whether two rule versions, each exact or a constraint,
+// can match the same request version.
+func versionsOverlap(a, b string) bool {
+	ca, cb := isVersionConstraint(a), isVersionConstraint(b)
+	switch {
+	case !ca && !cb:
+		return a == b
+	case !cb:
+		return constraintMatches(a, b)
+	case !ca:
+		return constraintMatches(b, a)
+	}
+	va, errA := parseVersionConstraint(a)
+	vb, errB := parseVersionConstraint(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	for _, x := range va.intervals() {
+		for _, y := range vb.intervals() {
+			if x.overlaps(y) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// constraintMatches reports whether a constraint matches an exact version.
+func constraintMatches(constraint, version string) bool {
+	c, err := parseVersionConstraint(constraint)
+	return err == nil && c.matches(version)
+}
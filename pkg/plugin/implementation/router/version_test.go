@@ -0,0 +1,73 @@
+package router
+
+import "testing"
+
+// TestVersionConstraintMatches tests matching versions against semver constraints.
+func TestVersionConstraintMatches(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{constraint: ">=1.1.0 <2.0.0", version: "1.1.0", want: true},
+		{constraint: ">=1.1.0 <2.0.0", version: "1.2.5", want: true},
+		{constraint: ">=1.1.0 <2.0.0", version: "2.0.0", want: false},
+		{constraint: ">=1.1.0 <2.0.0", version: "1.0.9", want: false},
+		{constraint: ">= 1.1 < 2", version: "1.1.0", want: true},
+		{constraint: ">1.0.0", version: "1.0.0", want: false},
+		{constraint: "<=2.0.0", version: "2.0.0", want: true},
+		{constraint: "!=1.2.0", version: "1.2.0", want: false},
+		{constraint: "=1.2.0", version: "v1.2.0", want: true},
+		{constraint: "<1.0.0 || >=2.0.0", version: "2.1.0", want: true},
+		{constraint: "<1.0.0 || >=2.0.0", version: "1.5.0", want: false},
+		{constraint: "*", version: "0.9.0", want: true},
+		{constraint: "*", version: "latest", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constraint+"/"+tt.version, func(t *testing.T) {
+			c, err := parseVersionConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("parseVersionConstraint() err = %v, want nil", err)
+			}
+			if got := c.matches(tt.version); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseVersionConstraintFailure tests that malformed constraints are rejected.
+func TestParseVersionConstraintFailure(t *testing.T) {
+	for _, constraint := range []string{"~1.2.0", "^1.0.0", ">=1.x", ">=1.0.0 ||", "1.2.3.4", "=>1.0.0"} {
+		t.Run(constraint, func(t *testing.T) {
+			if _, err := parseVersionConstraint(constraint); err == nil {
+				t.Errorf("parseVersionConstraint(%q) err = nil, want error", constraint)
+			}
+		})
+	}
+}
+
+// TestVersionsOverlap tests detecting rule versions that match the same request version.
+func TestVersionsOverlap(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "1.2.0", b: "1.2.0", want: true},
+		{a: "1.2.0", b: "2.0.0", want: false},
+		{a: ">=1.1.0 <2.0.0", b: "1.2.0", want: true},
+		{a: "2.0.0", b: ">=1.1.0 <2.0.0", want: false},
+		{a: ">=1.1.0 <2.0.0", b: ">=1.5.0", want: true},
+		{a: ">=1.1.0 <2.0.0", b: ">=2.0.0", want: false},
+		{a: "<=2.0.0", b: ">=2.0.0", want: true},
+		{a: "<1.0.0 || >=3.0.0", b: ">=2.0.0 <3.0.0", want: false},
+		{a: "*", b: ">=2.0.0 <3.0.0", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := versionsOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("versionsOverlap(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
@@ -15,13 +15,6 @@ import (
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
-// payload represents the structure of the data payload with context information.
-type payload struct {
-	Context struct {
-		Action string `json:"action"`
-	} `json:"context"`
-}
-
 // schemav2Validator implements the SchemaValidator interface.
 type schemav2Validator struct {
 	config    *Config
@@ -76,13 +69,12 @@ func New(ctx context.Context, config *Config) (*schemav2Validator, func() error,
 
 // Validate validates the given data against the OpenAPI schema.
 func (v *schemav2Validator) Validate(ctx context.Context, reqURL *url.URL, data []byte) error {
-	var payloadData payload
-	err := json.Unmarshal(data, &payloadData)
+	bc, err := model.BecknContextFor(ctx, data)
 	if err != nil {
 		return model.NewBadReqErr(fmt.Errorf("failed to parse JSON payload: %v", err))
 	}
 
-	if payloadData.Context.Action == "" {
+	if bc.Action == "" {
 		return model.NewBadReqErr(fmt.Errorf("missing field Action in context"))
 	}
 
@@ -94,7 +86,7 @@ func (v *schemav2Validator) Validate(ctx context.Context, reqURL *url.URL, data
 		return model.NewBadReqErr(fmt.Errorf("no OpenAPI spec loaded"))
 	}
 
-	action := payloadData.Context.Action
+	action := bc.Action
 	var schema *openapi3.SchemaRef
 	var matchedPath string
 
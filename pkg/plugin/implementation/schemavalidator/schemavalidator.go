@@ -16,14 +16,6 @@ import (
 	"github.com/santhosh-tekuri/jsonschema/v6"
 )
 
-// Payload represents the structure of the data payload with context information.
-type payload struct {
-	Context struct {
-		Domain  string `json:"domain"`
-		Version string `json:"version"`
-	} `json:"context"`
-}
-
 // schemaValidator implements the Validator interface.
 type schemaValidator struct {
 	config      *Config
@@ -55,22 +47,21 @@ func New(ctx context.Context, config *Config) (*schemaValidator, func() error, e
 
 // Validate validates the given data against the schema.
 func (v *schemaValidator) Validate(ctx context.Context, url *url.URL, data []byte) error {
-	var payloadData payload
-	err := json.Unmarshal(data, &payloadData)
+	bc, err := model.BecknContextFor(ctx, data)
 	if err != nil {
 		return model.NewBadReqErr(fmt.Errorf("failed to parse JSON payload: %v", err))
 	}
 
-	if payloadData.Context.Domain == "" {
+	if bc.Domain == "" {
 		return model.NewBadReqErr(fmt.Errorf("missing field Domain in context"))
 	}
-	if payloadData.Context.Version == "" {
+	if bc.Version == "" {
 		return model.NewBadReqErr(fmt.Errorf("missing field Version in context"))
 	}
 
 	// Extract domain, version, and endpoint from the payload and uri.
-	cxtDomain := payloadData.Context.Domain
-	version := payloadData.Context.Version
+	cxtDomain := bc.Domain
+	version := bc.Version
 	version = fmt.Sprintf("v%s", version)
 
 	endpoint := path.Base(url.String())
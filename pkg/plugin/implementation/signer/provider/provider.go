@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signer"
+)
+
+// SignerProvider implements the definition.SignerProvider interface.
+type SignerProvider struct{}
+
+// New creates a new Signer instance using the provided configuration.
+func (p SignerProvider) New(ctx context.Context, config map[string]string) (definition.Signer, func() error, error) {
+	if ctx == nil {
+		return nil, nil, errors.New("context cannot be nil")
+	}
+
+	cfg := &signer.Config{}
+	if v, ok := config["ttl"]; ok && v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid ttl %q: %w", v, err)
+		}
+		if ttl <= 0 {
+			return nil, nil, fmt.Errorf("invalid ttl %q: must be positive", v)
+		}
+		cfg.TTL = ttl
+	}
+	return signer.New(ctx, cfg)
+}
+
+// Provider is the exported symbol that the plugin manager will look for.
+var Provider = SignerProvider{}
+
+func init() {
+	if err := plugin.Register("signer", &Provider); err != nil {
+		log.Errorf(context.Background(), err, "Failed to register signer plugin")
+	}
+}
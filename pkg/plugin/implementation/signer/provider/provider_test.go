@@ -1,4 +1,4 @@
-package main
+package provider
 
 import (
 	"context"
@@ -35,14 +35,9 @@ func TestSignerProviderSuccess(t *testing.T) {
 			config: map[string]string{"ttl": ""},
 		},
 		{
-			name:   "Config with negative TTL",
+			name:   "Config with TTL",
 			ctx:    context.Background(),
-			config: map[string]string{"ttl": "-100"},
-		},
-		{
-			name:   "Config with non-numeric TTL",
-			ctx:    context.Background(),
-			config: map[string]string{"ttl": "not_a_number"},
+			config: map[string]string{"ttl": "10m"},
 		},
 	}
 
@@ -81,6 +76,18 @@ func TestSignerProviderFailure(t *testing.T) {
 			config:  map[string]string{},
 			wantErr: true,
 		},
+		{
+			name:    "Config with negative TTL",
+			ctx:     context.Background(),
+			config:  map[string]string{"ttl": "-100s"},
+			wantErr: true,
+		},
+		{
+			name:    "Config with non-numeric TTL",
+			ctx:     context.Background(),
+			config:  map[string]string{"ttl": "not_a_number"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range failureTests {
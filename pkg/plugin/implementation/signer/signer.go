@@ -6,12 +6,15 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"time"
 
 	"golang.org/x/crypto/blake2b"
 )
 
 // Config holds the configuration for the signing process.
 type Config struct {
+	// TTL is how long generated signatures stay valid. Zero uses the caller's default.
+	TTL time.Duration
 }
 
 // Signer implements the Signer interface and handles the signing process.
@@ -26,6 +29,14 @@ func New(ctx context.Context, config *Config) (*Signer, func() error, error) {
 	return s, nil, nil
 }
 
+// TTL returns the configured validity period of generated signatures.
+func (s *Signer) TTL() time.Duration {
+	if s.config == nil {
+		return 0
+	}
+	return s.config.TTL
+}
+
 // hash generates a signing string using BLAKE-512 hashing.
 func hash(payload []byte, createdAt, expiresAt int64) (string, error) {
 	hasher, _ := blake2b.New512(nil)
@@ -102,3 +102,11 @@ func TestSignFailure(t *testing.T) {
 		})
 	}
 }
+
+// TestTTL tests that the configured signature validity is reported to the caller.
+func TestTTL(t *testing.T) {
+	signer, _, _ := New(context.Background(), &Config{TTL: 10 * time.Minute})
+	if got := signer.TTL(); got != 10*time.Minute {
+		t.Errorf("TTL() = %v, want %v", got, 10*time.Minute)
+	}
+}
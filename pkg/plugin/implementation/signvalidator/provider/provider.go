@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/plugin"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+	"github.com/beckn-one/beckn-onix/pkg/plugin/implementation/signvalidator"
+)
+
+// provider provides instances of Verifier.
+type provider struct{}
+
+// New initializes a new Verifier instance.
+func (vp provider) New(ctx context.Context, config map[string]string) (definition.SignValidator, func() error, error) {
+	if ctx == nil {
+		return nil, nil, errors.New("context cannot be nil")
+	}
+
+	cfg := &signvalidator.Config{}
+	var err error
+	if cfg.AllowedSkew, err = parseDuration(config, "allowedSkew"); err != nil {
+		return nil, nil, err
+	}
+	if cfg.MaxValidity, err = parseDuration(config, "maxValidity"); err != nil {
+		return nil, nil, err
+	}
+	return signvalidator.New(ctx, cfg)
+}
+
+// parseDuration parses the optional non-negative duration stored under key.
+func parseDuration(config map[string]string, key string) (time.Duration, error) {
+	v, ok := config[key]
+	if !ok || v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", key, v, err)
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must not be negative", key, v)
+	}
+	return d, nil
+}
+
+// Provider is the exported symbol that the plugin manager will look for.
+var Provider = provider{}
+
+func init() {
+	if err := plugin.Register("signvalidator", &Provider); err != nil {
+		log.Errorf(context.Background(), err, "Failed to register signvalidator plugin")
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package provider
 
 import (
 	"context"
@@ -29,6 +29,11 @@ func TestVerifierProviderSuccess(t *testing.T) {
 			ctx:    context.Background(),
 			config: map[string]string{},
 		},
+		{
+			name:   "Skew and max validity",
+			ctx:    context.Background(),
+			config: map[string]string{"allowedSkew": "30s", "maxValidity": "1h"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -66,6 +71,18 @@ func TestVerifierProviderFailure(t *testing.T) {
 			config:  map[string]string{},
 			wantErr: true,
 		},
+		{
+			name:    "Invalid allowed skew",
+			ctx:     context.Background(),
+			config:  map[string]string{"allowedSkew": "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "Negative max validity",
+			ctx:     context.Background(),
+			config:  map[string]string{"maxValidity": "-1h"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
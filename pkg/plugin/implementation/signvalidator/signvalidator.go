@@ -15,6 +15,13 @@ import (
 
 // Config struct for Verifier.
 type Config struct {
+	// AllowedSkew is the clock difference tolerated when checking the created and
+	// expires timestamps of a signature.
+	AllowedSkew time.Duration
+
+	// MaxValidity is the longest accepted period between created and expires.
+	// Zero means no limit.
+	MaxValidity time.Duration
 }
 
 // validator implements the validator interface.
@@ -24,6 +31,9 @@ type validator struct {
 
 // New creates a new Verifier instance.
 func New(ctx context.Context, config *Config) (*validator, func() error, error) {
+	if config == nil {
+		config = &Config{}
+	}
 	v := &validator{config: config}
 
 	return v, nil, nil
@@ -41,13 +51,11 @@ func (v *validator) Validate(ctx context.Context, body []byte, header string, pu
 		return fmt.Errorf("error decoding signature: %w", err)
 	}
 
-	currentTime := time.Now().Unix()
-	if createdTimestamp > currentTime || currentTime > expiredTimestamp {
-		return model.NewSignValidationErr(fmt.Errorf("signature is expired or not yet valid"))
-	}
-
 	createdTime := time.Unix(createdTimestamp, 0)
 	expiredTime := time.Unix(expiredTimestamp, 0)
+	if err := v.checkValidity(createdTime, expiredTime, time.Now()); err != nil {
+		return model.NewSignValidationErr(err)
+	}
 
 	signingString := hash(body, createdTime.Unix(), expiredTime.Unix())
 
@@ -63,6 +71,30 @@ func (v *validator) Validate(ctx context.Context, body []byte, header string, pu
 	return nil
 }
 
+// AllowedSkew returns the clock skew tolerated when checking the timestamps of a signature.
+func (v *validator) AllowedSkew() time.Duration {
+	return v.config.AllowedSkew
+}
+
+// checkValidity checks the created and expires timestamps of a signature against now,
+// tolerating the configured clock skew, and enforces the maximum validity period.
+func (v *validator) checkValidity(created, expires, now time.Time) error {
+	skew := v.config.AllowedSkew
+	if expires.Before(created) {
+		return fmt.Errorf("signature expires at %s before it was created at %s", expires.UTC().Format(time.RFC3339), created.UTC().Format(time.RFC3339))
+	}
+	if created.After(now.Add(skew)) {
+		return fmt.Errorf("signature created at %s is in the future (allowed clock skew %s)", created.UTC().Format(time.RFC3339), skew)
+	}
+	if now.After(expires.Add(skew)) {
+		return fmt.Errorf("signature expired at %s (allowed clock skew %s)", expires.UTC().Format(time.RFC3339), skew)
+	}
+	if max := v.config.MaxValidity; max > 0 && expires.Sub(created) > max {
+		return fmt.Errorf("signature validity of %s exceeds the maximum of %s", expires.Sub(created), max)
+	}
+	return nil
+}
+
 // parseAuthHeader extracts signature values from the Authorization header.
 func parseAuthHeader(header string) (int64, int64, string, error) {
 	header = strings.TrimPrefix(header, "Signature ")
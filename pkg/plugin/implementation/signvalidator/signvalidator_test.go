@@ -5,6 +5,7 @@ import (
 	"crypto/ed25519"
 	"encoding/base64"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -145,3 +146,75 @@ func TestVerifyFailure(t *testing.T) {
 		})
 	}
 }
+
+// TestCheckValidity tests the clock skew tolerance and maximum validity of signatures.
+func TestCheckValidity(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		config  Config
+		created time.Time
+		expires time.Time
+		wantErr string
+	}{
+		{
+			name:    "Within validity",
+			created: now.Add(-time.Minute),
+			expires: now.Add(time.Minute),
+		},
+		{
+			name:    "Created in the future without skew",
+			created: now.Add(time.Second),
+			expires: now.Add(time.Minute),
+			wantErr: "is in the future (allowed clock skew 0s)",
+		},
+		{
+			name:    "Created in the future within skew",
+			config:  Config{AllowedSkew: 5 * time.Second},
+			created: now.Add(3 * time.Second),
+			expires: now.Add(time.Minute),
+		},
+		{
+			name:    "Expired within skew",
+			config:  Config{AllowedSkew: 5 * time.Second},
+			created: now.Add(-time.Minute),
+			expires: now.Add(-3 * time.Second),
+		},
+		{
+			name:    "Expired beyond skew",
+			config:  Config{AllowedSkew: 5 * time.Second},
+			created: now.Add(-time.Minute),
+			expires: now.Add(-10 * time.Second),
+			wantErr: "expired at 2023-11-14T22:13:10Z (allowed clock skew 5s)",
+		},
+		{
+			name:    "Validity exceeds maximum",
+			config:  Config{MaxValidity: 10 * time.Minute},
+			created: now,
+			expires: now.Add(time.Hour),
+			wantErr: "validity of 1h0m0s exceeds the maximum of 10m0s",
+		},
+		{
+			name:    "Expires before created",
+			created: now,
+			expires: now.Add(-time.Second),
+			wantErr: "before it was created",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, _, _ := New(context.Background(), &tt.config)
+			err := v.checkValidity(tt.created, tt.expires, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, but got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, but got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
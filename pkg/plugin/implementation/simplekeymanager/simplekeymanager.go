@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/beckn-one/beckn-onix/pkg/log"
+	"github.com/beckn-one/beckn-onix/pkg/metrics"
 	"github.com/beckn-one/beckn-onix/pkg/model"
 	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
 	"github.com/google/uuid"
@@ -251,9 +252,11 @@ func (skm *SimpleKeyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueK
 		var keys model.Keyset
 		if err := json.Unmarshal([]byte(cachedData), &keys); err == nil {
 			log.Debugf(ctx, "Found cached keys for subscriber: %s, uniqueKeyID: %s", subscriberID, uniqueKeyID)
+			metrics.ObserveKeyCache(true)
 			return keys.SigningPublic, keys.EncrPublic, nil
 		}
 	}
+	metrics.ObserveKeyCache(false)
 
 	log.Debugf(ctx, "Cache miss, looking up registry for subscriber: %s, uniqueKeyID: %s", subscriberID, uniqueKeyID)
 	subscribers, err := skm.Registry.Lookup(ctx, &model.Subscription{
@@ -262,6 +265,7 @@ func (skm *SimpleKeyMgr) LookupNPKeys(ctx context.Context, subscriberID, uniqueK
 		},
 		KeyID: uniqueKeyID,
 	})
+	metrics.ObserveRegistryLookup(err)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to lookup registry: %w", err)
 	}
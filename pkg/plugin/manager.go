@@ -28,32 +28,38 @@ type Manager struct {
 }
 
 func validateMgrCfg(cfg *ManagerConfig) error {
-	if cfg.Root == "" {
+	// A root path is optional only when every plugin is statically linked.
+	if cfg.Root == "" && len(Registered()) == 0 {
 		return fmt.Errorf("root path cannot be empty")
 	}
 	return nil
 }
 
 // NewManager initializes a new Manager instance by loading plugins from the specified configuration.
+// Statically registered providers are resolved first; .so files under the root are loaded for the rest.
 func NewManager(ctx context.Context, cfg *ManagerConfig) (*Manager, func(), error) {
 	if err := validateMgrCfg(cfg); err != nil {
 		return nil, nil, fmt.Errorf("Invalid config: %w", err)
 	}
-	log.Debugf(ctx, "RemoteRoot : %s", cfg.RemoteRoot)
-	if len(cfg.RemoteRoot) != 0 {
-		log.Debugf(ctx, "Unzipping files from  : %s to : %s", cfg.RemoteRoot, cfg.Root)
-		if err := unzip(cfg.RemoteRoot, cfg.Root); err != nil {
+	log.Debugf(ctx, "Statically linked plugins: %v", Registered())
+	loaded := map[string]onixPlugin{}
+	if cfg.Root != "" {
+		log.Debugf(ctx, "RemoteRoot : %s", cfg.RemoteRoot)
+		if len(cfg.RemoteRoot) != 0 {
+			log.Debugf(ctx, "Unzipping files from  : %s to : %s", cfg.RemoteRoot, cfg.Root)
+			if err := unzip(cfg.RemoteRoot, cfg.Root); err != nil {
+				return nil, nil, err
+			}
+		}
+		var err error
+		if loaded, err = plugins(ctx, cfg); err != nil {
 			return nil, nil, err
 		}
 	}
-	plugins, err := plugins(ctx, cfg)
-	if err != nil {
-		return nil, nil, err
-	}
 
-	closers := []func(){}
-	return &Manager{plugins: plugins, closers: closers}, func() {
-		for _, closer := range closers {
+	m := &Manager{plugins: loaded, closers: []func(){}}
+	return m, func() {
+		for _, closer := range m.closers {
 			closer()
 		}
 	}, nil
@@ -73,6 +79,10 @@ func plugins(ctx context.Context, cfg *ManagerConfig) (map[string]onixPlugin, er
 
 		if strings.HasSuffix(d.Name(), ".so") {
 			id := strings.TrimSuffix(d.Name(), ".so") // Extract plugin ID
+			if _, ok := staticProvider(id); ok {
+				log.Debugf(ctx, "Skipping plugin: %s, statically linked provider takes precedence", id)
+				return nil
+			}
 			p, elapsed, err := loadPlugin(ctx, path, id)
 			if err != nil {
 				return err
@@ -104,8 +114,17 @@ func loadPlugin(ctx context.Context, path, id string) (onixPlugin, time.Duration
 	return p, elapsed, nil
 }
 
+// provider resolves the Provider for the given ID, preferring statically registered
+// providers over the ones exported by dynamically loaded plugins.
 func provider[T any](plugins map[string]onixPlugin, id string) (T, error) {
 	var zero T
+	if sp, ok := staticProvider(id); ok {
+		pp, ok := sp.(T)
+		if !ok {
+			return zero, fmt.Errorf("failed to cast static Provider for %s", id)
+		}
+		return pp, nil
+	}
 	pgn, ok := plugins[id]
 	if !ok {
 		return zero, fmt.Errorf("plugin %s not found", id)
@@ -188,12 +207,29 @@ func (m *Manager) Router(ctx context.Context, cfg *Config) (definition.Router, e
 }
 
 // Middleware returns an HTTP middleware function based on the provided configuration.
+// The resources of middleware from a ClosingMiddlewareProvider are released when the
+// manager is closed.
 func (m *Manager) Middleware(ctx context.Context, cfg *Config) (func(http.Handler) http.Handler, error) {
 	mwp, err := provider[definition.MiddlewareProvider](m.plugins, cfg.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load provider for %s: %w", cfg.ID, err)
 	}
-	return mwp.New(ctx, cfg.Config)
+	cmp, ok := mwp.(definition.ClosingMiddlewareProvider)
+	if !ok {
+		return mwp.New(ctx, cfg.Config)
+	}
+	mw, closer, err := cmp.NewWithCloser(ctx, cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	if closer != nil {
+		m.closers = append(m.closers, func() {
+			if err := closer(); err != nil {
+				log.Errorf(ctx, err, "Failed to close middleware %s", cfg.ID)
+			}
+		})
+	}
+	return mw, nil
 }
 
 // Step returns a Step instance based on the provided configuration.
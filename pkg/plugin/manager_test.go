@@ -2433,6 +2433,50 @@ func TestMiddlewareSuccess(t *testing.T) {
 	}
 }
 
+// mockClosingMiddlewareProvider is a middleware provider whose middleware must be closed.
+type mockClosingMiddlewareProvider struct {
+	mockMiddlewareProvider
+	closed bool
+}
+
+func (m *mockClosingMiddlewareProvider) NewWithCloser(ctx context.Context, config map[string]string) (func(http.Handler) http.Handler, func() error, error) {
+	mw, err := m.New(ctx, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mw, func() error {
+		m.closed = true
+		return nil
+	}, nil
+}
+
+// TestMiddlewareCloser tests that middleware of a ClosingMiddlewareProvider is closed with the manager.
+func TestMiddlewareCloser(t *testing.T) {
+	p := &mockClosingMiddlewareProvider{}
+	m := &Manager{
+		plugins: map[string]onixPlugin{"test-middleware": &mockPlugin{symbol: p}},
+		closers: []func(){},
+	}
+	if _, err := m.Middleware(context.Background(), &Config{ID: "test-middleware"}); err != nil {
+		t.Fatalf("Middleware() error = %v", err)
+	}
+	if len(m.closers) != 1 {
+		t.Fatalf("closers = %d, want 1", len(m.closers))
+	}
+	m.closers[0]()
+	if !p.closed {
+		t.Error("middleware was not closed")
+	}
+
+	p.err = errors.New("provider error")
+	if _, err := m.Middleware(context.Background(), &Config{ID: "test-middleware"}); err == nil {
+		t.Error("Middleware() error = nil, want provider error")
+	}
+	if len(m.closers) != 1 {
+		t.Errorf("closers = %d after a failure, want 1", len(m.closers))
+	}
+}
+
 // TestManagerMiddlewareFailure tests the failure scenarios of the Middleware method.
 func TestMiddlewareFailure(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,56 @@
+package plugin
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	staticMu        sync.RWMutex
+	staticProviders = make(map[string]any) // staticProviders holds providers linked into the binary, keyed by plugin ID.
+)
+
+// Register makes a plugin provider available to the Manager under the given ID
+// without loading a .so file. It is meant to be called from the init function of
+// a plugin's provider package, so that importing the package links the plugin in.
+//
+// A plugin built as a .so imports the same provider package, so its init also runs
+// when the Manager loads it. Register therefore returns an error instead of
+// panicking if the ID is empty, the provider is nil or the ID is already registered,
+// and the providers log it: the provider registered first keeps its ID.
+func Register(id string, provider any) error {
+	staticMu.Lock()
+	defer staticMu.Unlock()
+	if id == "" {
+		return fmt.Errorf("plugin: Register called with empty id")
+	}
+	if provider == nil {
+		return fmt.Errorf("plugin: Register provider for %s is nil", id)
+	}
+	if _, dup := staticProviders[id]; dup {
+		return fmt.Errorf("plugin: %s is already registered", id)
+	}
+	staticProviders[id] = provider
+	return nil
+}
+
+// Registered returns the sorted IDs of all statically registered providers.
+func Registered() []string {
+	staticMu.RLock()
+	defer staticMu.RUnlock()
+	ids := make([]string, 0, len(staticProviders))
+	for id := range staticProviders {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// staticProvider returns the statically registered provider for the given ID, if any.
+func staticProvider(id string) (any, bool) {
+	staticMu.RLock()
+	defer staticMu.RUnlock()
+	p, ok := staticProviders[id]
+	return p, ok
+}
@@ -0,0 +1,134 @@
+package plugin
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/beckn-one/beckn-onix/pkg/plugin/definition"
+)
+
+// registerForTest registers a provider and removes it once the test completes.
+func registerForTest(t *testing.T, id string, p any) {
+	t.Helper()
+	if err := Register(id, p); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	t.Cleanup(func() {
+		staticMu.Lock()
+		delete(staticProviders, id)
+		staticMu.Unlock()
+	})
+}
+
+// TestRegisterSuccess tests that registered providers are listed and resolved.
+func TestRegisterSuccess(t *testing.T) {
+	registerForTest(t, "static-router", &mockRouterProvider{router: &mockRouter{}})
+	registerForTest(t, "static-cache", &mockCacheProvider{cache: &mockCache{}})
+
+	if got, want := Registered(), []string{"static-cache", "static-router"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Registered() = %v, want %v", got, want)
+	}
+
+	rp, err := provider[definition.RouterProvider](nil, "static-router")
+	if err != nil {
+		t.Fatalf("provider() error = %v, want nil", err)
+	}
+	if rp == nil {
+		t.Fatal("provider() returned nil RouterProvider")
+	}
+}
+
+// TestRegisterFailure tests that invalid registrations are rejected without
+// replacing the registered provider.
+func TestRegisterFailure(t *testing.T) {
+	first := &mockRouterProvider{}
+	registerForTest(t, "static-dup", first)
+
+	tests := []struct {
+		name string
+		id   string
+		p    any
+	}{
+		{name: "empty id", id: "", p: &mockRouterProvider{}},
+		{name: "nil provider", id: "static-nil", p: nil},
+		{name: "duplicate id", id: "static-dup", p: &mockRouterProvider{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Register(tt.id, tt.p); err == nil {
+				t.Fatal("Register() error = nil, want error")
+			}
+		})
+	}
+	if p, _ := staticProvider("static-dup"); p != first {
+		t.Error("duplicate Register() replaced the registered provider")
+	}
+}
+
+// TestStaticProviderPrecedence tests that a static provider wins over a loaded plugin with the same ID.
+func TestStaticProviderPrecedence(t *testing.T) {
+	static := &mockRouterProvider{router: &mockRouter{}}
+	registerForTest(t, "static-router", static)
+
+	plugins := map[string]onixPlugin{
+		"static-router": &mockPlugin{symbol: &mockRouterProvider{}},
+	}
+	rp, err := provider[definition.RouterProvider](plugins, "static-router")
+	if err != nil {
+		t.Fatalf("provider() error = %v, want nil", err)
+	}
+	if rp != static {
+		t.Fatal("provider() did not return the statically registered provider")
+	}
+}
+
+// TestStaticProviderCastFailure tests that a static provider of the wrong type is rejected.
+func TestStaticProviderCastFailure(t *testing.T) {
+	registerForTest(t, "static-cache", &mockCacheProvider{})
+
+	if _, err := provider[definition.RouterProvider](nil, "static-cache"); err == nil {
+		t.Fatal("provider() expected error, got nil")
+	}
+}
+
+// TestNewManagerStatic tests a manager built only from statically linked plugins.
+func TestNewManagerStatic(t *testing.T) {
+	registerForTest(t, "static-router", &mockRouterProvider{router: &mockRouter{}})
+
+	m, cleanup, err := NewManager(context.Background(), &ManagerConfig{})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v, want nil", err)
+	}
+	defer cleanup()
+
+	r, err := m.Router(context.Background(), &Config{ID: "static-router"})
+	if err != nil {
+		t.Fatalf("Router() error = %v, want nil", err)
+	}
+	if r == nil {
+		t.Fatal("Router() returned nil")
+	}
+}
+
+// TestPluginsSkipsStatic tests that .so files shadowed by a static provider are not opened.
+func TestPluginsSkipsStatic(t *testing.T) {
+	registerForTest(t, "static-router", &mockRouterProvider{})
+
+	root := t.TempDir()
+	// An invalid .so would fail to open if the manager tried to load it.
+	if err := os.WriteFile(filepath.Join(root, "static-router.so"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	got, err := plugins(context.Background(), &ManagerConfig{Root: root})
+	if err != nil {
+		t.Fatalf("plugins() error = %v, want nil", err)
+	}
+	if _, ok := got["static-router"]; ok {
+		t.Fatal("plugins() loaded a plugin shadowed by a static provider")
+	}
+}
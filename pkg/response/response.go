@@ -68,6 +68,11 @@ func SendNack(ctx context.Context, w http.ResponseWriter, err error) {
 	var signErr *model.SignValidationErr
 	var badReqErr *model.BadReqErr
 	var notFoundErr *model.NotFoundErr
+	var unavailableErr *model.UnavailableErr
+	var replayErr *model.ReplayErr
+	var badGatewayErr *model.BadGatewayErr
+	var tooLargeErr *model.PayloadTooLargeErr
+	var tooManyErr *model.TooManyRequestsErr
 
 	switch {
 	case errors.As(err, &schemaErr):
@@ -82,6 +87,21 @@ func SendNack(ctx context.Context, w http.ResponseWriter, err error) {
 	case errors.As(err, &notFoundErr):
 		nack(ctx, w, notFoundErr.BecknError(), http.StatusNotFound)
 		return
+	case errors.As(err, &unavailableErr):
+		nack(ctx, w, unavailableErr.BecknError(), http.StatusServiceUnavailable)
+		return
+	case errors.As(err, &replayErr):
+		nack(ctx, w, replayErr.BecknError(), http.StatusConflict)
+		return
+	case errors.As(err, &badGatewayErr):
+		nack(ctx, w, badGatewayErr.BecknError(), http.StatusBadGateway)
+		return
+	case errors.As(err, &tooLargeErr):
+		nack(ctx, w, tooLargeErr.BecknError(), http.StatusRequestEntityTooLarge)
+		return
+	case errors.As(err, &tooManyErr):
+		nack(ctx, w, tooManyErr.BecknError(), http.StatusTooManyRequests)
+		return
 	default:
 		nack(ctx, w, internalServerError(ctx), http.StatusInternalServerError)
 		return
@@ -83,6 +83,36 @@ func TestSendNack(t *testing.T) {
 			status:   http.StatusNotFound,
 			expected: `{"message":{"ack":{"status":"NACK"},"error":{"code":"Not Found","message":"Endpoint not found: endpoint not found"}}}`,
 		},
+		{
+			name:     "UnavailableErr",
+			err:      model.NewUnavailableErr(errors.New("circuit open")),
+			status:   http.StatusServiceUnavailable,
+			expected: `{"message":{"ack":{"status":"NACK"},"error":{"code":"Service Unavailable","message":"Service Unavailable: circuit open"}}}`,
+		},
+		{
+			name:     "ReplayErr",
+			err:      model.NewReplayErr(errors.New("duplicate signature")),
+			status:   http.StatusConflict,
+			expected: `{"message":{"ack":{"status":"NACK"},"error":{"code":"Conflict","message":"Replayed Request: duplicate signature"}}}`,
+		},
+		{
+			name:     "BadGatewayErr",
+			err:      model.NewBadGatewayErr(errors.New("invalid response")),
+			status:   http.StatusBadGateway,
+			expected: `{"message":{"ack":{"status":"NACK"},"error":{"code":"Bad Gateway","message":"Bad Gateway: invalid response"}}}`,
+		},
+		{
+			name:     "PayloadTooLargeErr",
+			err:      model.NewPayloadTooLargeErr(errors.New("body exceeds 1024 bytes")),
+			status:   http.StatusRequestEntityTooLarge,
+			expected: `{"message":{"ack":{"status":"NACK"},"error":{"code":"Request Entity Too Large","message":"Payload Too Large: body exceeds 1024 bytes"}}}`,
+		},
+		{
+			name:     "TooManyRequestsErr",
+			err:      model.NewTooManyRequestsErr(errors.New("rate limit exceeded")),
+			status:   http.StatusTooManyRequests,
+			expected: `{"message":{"ack":{"status":"NACK"},"error":{"code":"Too Many Requests","message":"Too Many Requests: rate limit exceeded"}}}`,
+		},
 		{
 			name:     "InternalServerError",
 			err:      errors.New("unexpected error"),
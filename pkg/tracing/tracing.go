@@ -0,0 +1,193 @@
+// Package tracing sets up OpenTelemetry tracing for the adapter and provides
+// helpers for starting spans and propagating W3C trace context.
+//
+// Spans are created through the global tracer provider, so the core handlers and
+// the plugins, which share this package when loaded into the adapter, report into
+// the same trace. When tracing is not initialised the global provider is a no-op.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/beckn-one/beckn-onix/pkg/log"
+)
+
+// instrumentationName identifies the tracer used for all adapter spans.
+const instrumentationName = "github.com/beckn-one/beckn-onix"
+
+// Exporter identifies where spans are sent.
+type Exporter string
+
+const (
+	// ExporterOTLPGRPC sends spans to an OTLP collector over gRPC.
+	ExporterOTLPGRPC Exporter = "otlp"
+	// ExporterOTLPHTTP sends spans to an OTLP collector over HTTP.
+	ExporterOTLPHTTP Exporter = "otlphttp"
+	// ExporterStdout writes spans to standard output, for debugging.
+	ExporterStdout Exporter = "stdout"
+)
+
+// Config holds the tracing configuration.
+type Config struct {
+	// Enabled switches tracing on.
+	Enabled bool `yaml:"enabled"`
+
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `yaml:"serviceName"`
+
+	// Exporter selects where spans are sent. Defaults to otlp.
+	Exporter Exporter `yaml:"exporter"`
+
+	// Endpoint is the collector address, e.g. localhost:4317 for gRPC or localhost:4318 for HTTP.
+	// If empty, the exporter's default or the OTEL_EXPORTER_OTLP_ENDPOINT environment variable is used.
+	Endpoint string `yaml:"endpoint"`
+
+	// Insecure disables TLS for the connection to the collector.
+	Insecure bool `yaml:"insecure"`
+
+	// SampleRatio is the fraction of new traces that are sampled, between 0 and 1.
+	// Traces started upstream follow the caller's sampling decision. Defaults to 1.
+	SampleRatio *float64 `yaml:"sampleRatio"`
+}
+
+// Init configures the global tracer provider and the W3C trace context propagator.
+// It returns a function that flushes and shuts down the provider. If tracing is
+// disabled, Init installs only the propagator so trace context is still forwarded.
+func Init(ctx context.Context, cfg Config) (func(), error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !cfg.Enabled {
+		return func() {}, nil
+	}
+
+	exp, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	ratio := 1.0
+	if cfg.SampleRatio != nil {
+		ratio = *cfg.SampleRatio
+	}
+	if ratio < 0 || ratio > 1 {
+		return nil, fmt.Errorf("invalid sampleRatio %v: must be between 0 and 1", ratio)
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+	)
+	otel.SetTracerProvider(tp)
+	log.Infof(ctx, "Tracing enabled with %s exporter", cfg.Exporter)
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := tp.Shutdown(shutdownCtx); err != nil {
+			log.Errorf(ctx, err, "Failed to shut down tracer provider")
+		}
+	}, nil
+}
+
+// newExporter creates the span exporter selected in the config.
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLPGRPC, "":
+		opts := []otlptracegrpc.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		exp, err := otlptracegrpc.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP gRPC exporter: %w", err)
+		}
+		return exp, nil
+	case ExporterOTLPHTTP:
+		opts := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		exp, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP HTTP exporter: %w", err)
+		}
+		return exp, nil
+	case ExporterStdout:
+		exp, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stdout exporter: %w", err)
+		}
+		return exp, nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %s", cfg.Exporter)
+	}
+}
+
+// Start starts a span as a child of the span in ctx.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// End records err on the span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject writes the trace context of ctx into carrier, e.g. outgoing HTTP or message headers.
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract returns ctx with the trace context read from carrier, e.g. incoming HTTP headers.
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// MapCarrier adapts a map of message headers, such as AMQP headers, to a TextMapCarrier.
+type MapCarrier map[string]any
+
+// Get returns the string value for key.
+func (c MapCarrier) Get(key string) string {
+	v, _ := c[key].(string)
+	return v
+}
+
+// Set stores the value for key.
+func (c MapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys lists the keys in the carrier.
+func (c MapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,111 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useRecorder installs a tracer provider that records spans for the duration of the test.
+func useRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return rec
+}
+
+// TestInitSuccess tests the supported tracing configurations.
+func TestInitSuccess(t *testing.T) {
+	ratio := 0.5
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "disabled", cfg: Config{}},
+		{name: "stdout exporter", cfg: Config{Enabled: true, ServiceName: "test", Exporter: ExporterStdout, SampleRatio: &ratio}},
+		{name: "otlp http exporter", cfg: Config{Enabled: true, Exporter: ExporterOTLPHTTP, Endpoint: "localhost:4318", Insecure: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := otel.GetTracerProvider()
+			t.Cleanup(func() { otel.SetTracerProvider(prev) })
+
+			shutdown, err := Init(context.Background(), tt.cfg)
+			if err != nil {
+				t.Fatalf("Init() error = %v, want nil", err)
+			}
+			shutdown()
+		})
+	}
+}
+
+// TestInitFailure tests that invalid tracing configurations are rejected.
+func TestInitFailure(t *testing.T) {
+	ratio := 2.0
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "unknown exporter", cfg: Config{Enabled: true, Exporter: "zipkin"}},
+		{name: "invalid sample ratio", cfg: Config{Enabled: true, Exporter: ExporterStdout, SampleRatio: &ratio}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Init(context.Background(), tt.cfg); err == nil {
+				t.Fatal("Init() error = nil, want error")
+			}
+		})
+	}
+}
+
+// TestEnd tests that errors are recorded on the span.
+func TestEnd(t *testing.T) {
+	rec := useRecorder(t)
+
+	_, span := Start(context.Background(), "ok")
+	End(span, nil)
+	_, span = Start(context.Background(), "failed")
+	End(span, errors.New("lookup failed"))
+
+	spans := rec.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("ended spans = %d, want 2", len(spans))
+	}
+	if got := spans[0].Status().Code; got != codes.Unset {
+		t.Errorf("status of successful span = %v, want %v", got, codes.Unset)
+	}
+	if got := spans[1].Status().Code; got != codes.Error {
+		t.Errorf("status of failed span = %v, want %v", got, codes.Error)
+	}
+}
+
+// TestPropagation tests that trace context survives a round trip through message headers.
+func TestPropagation(t *testing.T) {
+	if _, err := Init(context.Background(), Config{}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	useRecorder(t)
+	ctx, span := Start(context.Background(), "publish")
+	defer span.End()
+
+	headers := MapCarrier{}
+	Inject(ctx, headers)
+	if headers.Get("traceparent") == "" {
+		t.Fatal("Inject() did not set traceparent")
+	}
+
+	got := trace.SpanContextFromContext(Extract(context.Background(), headers))
+	if got.TraceID() != span.SpanContext().TraceID() || got.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Extract() = %v, want %v", got, span.SpanContext())
+	}
+}